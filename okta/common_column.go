@@ -3,7 +3,8 @@ package okta
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
@@ -13,7 +14,7 @@ import (
 )
 
 func commonColumns(c []*plugin.Column) []*plugin.Column {
-	return append([]*plugin.Column{
+	common := []*plugin.Column{
 		{
 			Name:        "domain",
 			Description: "The okta domain name.",
@@ -21,7 +22,63 @@ func commonColumns(c []*plugin.Column) []*plugin.Column {
 			Type:        proto.ColumnType_STRING,
 			Transform:   transform.FromValue(),
 		},
-	}, c...)
+		{
+			Name:        "org_id",
+			Description: "The unique identifier of the Okta org.",
+			Hydrate:     getOktaOrgId,
+			Type:        proto.ColumnType_STRING,
+			Transform:   transform.FromValue(),
+		},
+		{
+			Name:        "org_domain",
+			Description: "The domain name of the Okta org, e.g. acme.okta.com, even if the connection uses a custom domain.",
+			Hydrate:     getOktaOrgDomain,
+			Type:        proto.ColumnType_STRING,
+			Transform:   transform.FromValue(),
+		},
+		{
+			Name:        "raw",
+			Description: "The object returned by the Okta API for the row, including the attributes that have no column of their own.",
+			Type:        proto.ColumnType_JSON,
+			Transform:   transform.From(rawObject),
+		},
+	}
+
+	// Columns of the table itself take precedence, e.g. the org_id of okta_connection_info
+	columns := make([]*plugin.Column, 0, len(common)+len(c))
+	for _, column := range common {
+		if !slices.ContainsFunc(c, func(tableColumn *plugin.Column) bool { return tableColumn.Name == column.Name }) {
+			columns = append(columns, column)
+		}
+	}
+
+	// The SDK clients decode timestamps to different types, so they are all
+	// converted the same way
+	for _, column := range c {
+		if column.Type != proto.ColumnType_TIMESTAMP {
+			continue
+		}
+		if column.Transform == nil {
+			column.Transform = transform.FromCamel()
+		}
+		column.Transform = column.Transform.Transform(timestampUTC)
+	}
+	return append(columns, c...)
+}
+
+// rawObjectItem is implemented by the row types of tables wrapping the object
+// returned by the API with fields of their own
+type rawObjectItem interface {
+	RawObject() interface{}
+}
+
+// rawObject returns the object returned by the API for the row, e.g. the
+// actual factor type of a factor rather than the fields the table maps
+func rawObject(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	if item, ok := d.HydrateItem.(rawObjectItem); ok {
+		return item.RawObject(), nil
+	}
+	return d.HydrateItem, nil
 }
 
 // if the caching is required other than per connection, build a cache key for the call and use it in Memoize.
@@ -40,24 +97,82 @@ func getOktaDomainNameCacheKey(ctx context.Context, d *plugin.QueryData, h *plug
 }
 
 func getOktaDomainNameUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	// Retrieve the Okta configuration
+	// Retrieve the domain from the config, falling back to the environment variable
 	config := GetConfig(d.Connection)
-
-	// Retrieve the domain from the config
-	domain := config.Domain
-
-	// If the domain is not set in the config, fall back to the environment variable
-	if domain == nil {
-		envDomain := os.Getenv("OKTA_CLIENT_ORGURL")
-		domain = &envDomain
+	orgURL, err := normalizeOrgURL(getStringValue(config.Domain, "OKTA_CLIENT_ORGURL"))
+	if err != nil {
+		return nil, err
 	}
 
 	// Extract the domain name by removing the "https://" prefix
-	splitDomain := strings.SplitN(*domain, "https://", 2)
-	if len(splitDomain) != 2 {
-		return nil, fmt.Errorf("invalid okta domain format: %s", *domain)
+	domainName, ok := strings.CutPrefix(orgURL, "https://")
+	if !ok {
+		return nil, fmt.Errorf("invalid okta domain format: %s", orgURL)
 	}
-	domainName := splitDomain[1]
 
 	return domainName, nil
 }
+
+// oktaOrg identifies the Okta org of a connection
+type oktaOrg struct {
+	Id     string
+	Domain string
+}
+
+// if the caching is required other than per connection, build a cache key for the call and use it in Memoize.
+var getOktaOrgMemoized = plugin.HydrateFunc(getOktaOrgUncached).Memoize(memoize.WithCacheKeyFunction(getOktaOrgCacheKey))
+
+// declare wrapper hydrate functions to call the memoized function
+// - this is required when a memoized function is used for a column definition
+func getOktaOrgId(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	org, err := getOktaOrgMemoized(ctx, d, h)
+	if err != nil {
+		return nil, err
+	}
+	return org.(*oktaOrg).Id, nil
+}
+
+func getOktaOrgDomain(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	org, err := getOktaOrgMemoized(ctx, d, h)
+	if err != nil {
+		return nil, err
+	}
+	return org.(*oktaOrg).Domain, nil
+}
+
+// Build a cache key for the call to getOktaOrgCacheKey.
+func getOktaOrgCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := "getOktaOrg"
+	return key, nil
+}
+
+func getOktaOrgUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := ConnectV5(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// The well-known org metadata endpoint doesn't require any additional scopes
+	metadata, _, err := client.OrgSettingAPI.GetWellknownOrgMetadata(ctx).Execute()
+	if err != nil {
+		return nil, err
+	}
+	org := &oktaOrg{Id: metadata.GetId()}
+
+	// The organization link is the URL of the org on its Okta cell, whatever
+	// the domain the connection uses
+	if links, ok := metadata.GetLinksOk(); ok {
+		if orgURL, err := url.Parse(links.Organization.GetHref()); err == nil {
+			org.Domain = orgURL.Host
+		}
+	}
+	if org.Domain == "" {
+		domain, err := getOktaDomainName(ctx, d, h)
+		if err != nil {
+			return nil, err
+		}
+		org.Domain = domain.(string)
+	}
+
+	return org, nil
+}
@@ -2,9 +2,14 @@ package okta
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	oktaV4 "github.com/okta/okta-sdk-golang/v4/okta"
@@ -13,36 +18,125 @@ import (
 )
 
 func Connect(ctx context.Context, d *plugin.QueryData) (*okta.Client, error) {
+	client, err := getOrCreateClient(ctx, d, "OktaSession", func() (interface{}, error) {
+		return newOktaClient(ctx, d.Connection)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*okta.Client), nil
+}
+
+// Locks held while the clients of a connection are built
+var clientLocks sync.Map
+
+// getOrCreateClient returns the client cached for the connection under the
+// key, building it if needed. Concurrent callers wait for the first one to
+// build the client, so the clients of a connection, and the OAuth access
+// token each of them requests and refreshes when it expires, are only
+// created once rather than once per hydrate call running at startup. The API
+// calls of the query are counted from then on, see trackQueryMetrics.
+func getOrCreateClient(ctx context.Context, d *plugin.QueryData, sessionCacheKey string, create func() (interface{}, error)) (interface{}, error) {
+	if err := checkTableScopes(d); err != nil {
+		return nil, err
+	}
+	trackQueryMetrics(ctx, d)
+
 	// have we already created and cached the session?
-	sessionCacheKey := "OktaSession"
 	if cachedData, ok := d.ConnectionManager.Cache.Get(sessionCacheKey); ok {
-		return cachedData.(*okta.Client), nil
+		return cachedData, nil
+	}
+
+	lock, _ := clientLocks.LoadOrStore(fmt.Sprintf("%s-%s", d.Connection.Name, sessionCacheKey), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// The client may have been built while waiting for the lock
+	if cachedData, ok := d.ConnectionManager.Cache.Get(sessionCacheKey); ok {
+		return cachedData, nil
+	}
+
+	client, err := create()
+	if err != nil {
+		return nil, err
+	}
+
+	// Save session into cache
+	d.ConnectionManager.Cache.Set(sessionCacheKey, client)
+
+	return client, nil
+}
+
+// Clients used while building the dynamic table schemas, when no connection
+// cache is available, keyed by connection name and a hash of its config
+var schemaClients sync.Map
+
+// getSchemaClient returns a client for the connection to read the schemas
+// the dynamic tables are built from. The client is shared by the tables of
+// the connection and rebuilt when its config changes.
+func getSchemaClient(ctx context.Context, connection *plugin.Connection) (*okta.Client, error) {
+	config, err := json.Marshal(GetConfig(connection))
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s-%x", connection.Name, sha256.Sum256(config))
+	if client, ok := schemaClients.Load(key); ok {
+		return client.(*okta.Client), nil
+	}
+
+	client, err := newOktaClient(ctx, connection)
+	if err != nil {
+		return nil, err
 	}
+	schemaClients.Store(key, client)
+
+	return client, nil
+}
 
+// newOktaClient creates an uncached client for the given connection. It is
+// used directly when no query data is available, e.g. while building the
+// dynamic table schemas.
+func newOktaClient(ctx context.Context, connection *plugin.Connection) (*okta.Client, error) {
 	// Get environment or steampipe config value
-	domain, token, clientID, privateKey, requestTimeout, maxBackoff, maxRetries, err := getOktaConfigValues(d)
+	config, err := getOktaConfigValues(connection)
 	if err != nil {
 		return nil, fmt.Errorf("error in retrieving config or environment values: %v", err)
 	}
+	// Rate limited requests are retried by the HTTP transport, so the SDK
+	// doesn't retry them again
+	httpClient, err := getHTTPClient(connection, config)
+	if err != nil {
+		return nil, err
+	}
 
-	scopes := []string{"okta.users.read", "okta.groups.read", "okta.roles.read", "okta.apps.read", "okta.policies.read", "okta.authorizationServers.read", "okta.trustedOrigins.read", "okta.factors.read"}
+	if config.domain != "" && config.token != "" {
+		_, client, err := okta.NewClient(ctx, okta.WithOrgUrl(config.domain), okta.WithToken(config.token), okta.WithHttpClientPtr(httpClient), okta.WithUserAgentExtra(config.userAgentExtra), okta.WithRequestTimeout(config.callTimeout), okta.WithRateLimitMaxRetries(0), okta.WithRateLimitMaxBackOff(config.maxBackoff))
+		return client, err
+	}
 
-	if domain != "" && token != "" {
-		_, client, err := okta.NewClient(ctx, okta.WithOrgUrl(domain), okta.WithToken(token), okta.WithRequestTimeout(requestTimeout), okta.WithRateLimitMaxRetries(maxRetries), okta.WithRateLimitMaxBackOff(maxBackoff))
+	// The HTTP transport sends the latest token printed by the command
+	if config.domain != "" && config.tokenCmd != "" {
+		token, err := getCommandToken(config.tokenCmd)
 		if err != nil {
 			return nil, err
 		}
-		d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-		client.GetConfig()
+		_, client, err := okta.NewClient(ctx, okta.WithOrgUrl(config.domain), okta.WithToken(token), okta.WithHttpClientPtr(httpClient), okta.WithUserAgentExtra(config.userAgentExtra), okta.WithRequestTimeout(config.callTimeout), okta.WithRateLimitMaxRetries(0), okta.WithRateLimitMaxBackOff(config.maxBackoff))
 		return client, err
 	}
 
-	if domain != "" && clientID != "" && privateKey != "" {
-		_, client, err := okta.NewClient(ctx, okta.WithOrgUrl(domain), okta.WithAuthorizationMode("PrivateKey"), okta.WithClientId(clientID), okta.WithPrivateKey(privateKey), okta.WithScopes(scopes), okta.WithRequestTimeout(requestTimeout), okta.WithRateLimitMaxRetries(maxRetries), okta.WithRateLimitMaxBackOff(maxBackoff))
+	// The v2 SDK has no Bearer authorization mode, so the access token is set
+	// as an API token and sent with the Bearer scheme by the HTTP transport
+	if config.domain != "" && config.accessToken != "" {
+		_, client, err := okta.NewClient(ctx, okta.WithOrgUrl(config.domain), okta.WithToken(config.accessToken), okta.WithHttpClientPtr(httpClient), okta.WithUserAgentExtra(config.userAgentExtra), okta.WithRequestTimeout(config.callTimeout), okta.WithRateLimitMaxRetries(0), okta.WithRateLimitMaxBackOff(config.maxBackoff))
+		return client, err
+	}
+
+	if config.domain != "" && config.clientID != "" && config.privateKey != "" {
+		signer, err := newPrivateKeySigner(config.privateKey, config.privateKeyID)
 		if err != nil {
 			return nil, err
 		}
-		d.ConnectionManager.Cache.Set(sessionCacheKey, client)
+		_, client, err := okta.NewClient(ctx, okta.WithOrgUrl(config.domain), okta.WithAuthorizationMode("PrivateKey"), okta.WithClientId(config.clientID), okta.WithPrivateKeySigner(signer), okta.WithScopes(config.getScopes(defaultScopes)), okta.WithHttpClientPtr(httpClient), okta.WithUserAgentExtra(config.userAgentExtra), okta.WithRequestTimeout(config.callTimeout), okta.WithRateLimitMaxRetries(0), okta.WithRateLimitMaxBackOff(config.maxBackoff))
 		return client, err
 	}
 
@@ -55,52 +149,68 @@ func Connect(ctx context.Context, d *plugin.QueryData) (*okta.Client, error) {
 	* 3. Environment variables
 	* 4. Configuration explicitly passed to the constructor (see the example in Getting started)
 	*	*/
-	_, client, err := okta.NewClient(ctx, okta.WithRequestTimeout(requestTimeout), okta.WithRateLimitMaxRetries(maxRetries), okta.WithRateLimitMaxBackOff(maxBackoff))
-	if err != nil {
-		return nil, err
-	}
-
-	// Save session into cache
-	d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-
+	_, client, err := okta.NewClient(ctx, okta.WithHttpClientPtr(httpClient), okta.WithUserAgentExtra(config.userAgentExtra), okta.WithRequestTimeout(config.callTimeout), okta.WithRateLimitMaxRetries(0), okta.WithRateLimitMaxBackOff(config.maxBackoff))
 	return client, err
 }
 
 func ConnectV4(ctx context.Context, d *plugin.QueryData) (*oktaV4.APIClient, error) {
-	// have we already created and cached the session?
-	sessionCacheKey := "OktaSessionV4"
-	if cachedData, ok := d.ConnectionManager.Cache.Get(sessionCacheKey); ok {
-		return cachedData.(*oktaV4.APIClient), nil
+	client, err := getOrCreateClient(ctx, d, "OktaSessionV4", func() (interface{}, error) {
+		return newOktaClientV4(d.Connection)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return client.(*oktaV4.APIClient), nil
+}
 
+func newOktaClientV4(connection *plugin.Connection) (*oktaV4.APIClient, error) {
 	// Get environment or steampipe config value
-	domain, token, clientID, privateKey, requestTimeout, maxBackoff, maxRetries, err := getOktaConfigValues(d)
+	config, err := getOktaConfigValues(connection)
 	if err != nil {
 		return nil, fmt.Errorf("error in retrieving config or environment values: %v", err)
 	}
-	scopes := []string{"okta.users.read", "okta.groups.read", "okta.roles.read", "okta.apps.read", "okta.policies.read", "okta.authorizationServers.read", "okta.trustedOrigins.read", "okta.factors.read", "okta.devices.read"}
+	// Rate limited requests are retried by the HTTP transport, so the SDK
+	// doesn't retry them again
+	httpClient, err := getHTTPClient(connection, config)
+	if err != nil {
+		return nil, err
+	}
 
-	if domain != "" && token != "" {
-		oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithOrgUrl(domain), oktaV4.WithToken(token), oktaV4.WithRequestTimeout(requestTimeout), oktaV4.WithRateLimitMaxRetries(maxRetries), oktaV4.WithRateLimitMaxBackOff(maxBackoff))
+	if config.domain != "" && config.token != "" {
+		oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithOrgUrl(config.domain), oktaV4.WithToken(config.token), oktaV4.WithHttpClientPtr(httpClient), oktaV4.WithUserAgentExtra(config.userAgentExtra), oktaV4.WithRequestTimeout(config.callTimeout), oktaV4.WithRateLimitMaxRetries(0), oktaV4.WithRateLimitMaxBackOff(config.maxBackoff))
 		if err != nil {
 			return nil, err
 		}
-		client := oktaV4.NewAPIClient(oktaConfiguratiopn)
+		return oktaV4.NewAPIClient(oktaConfiguratiopn), nil
+	}
 
-		d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-		return client, err
+	if config.domain != "" && config.tokenCmd != "" {
+		token, err := getCommandToken(config.tokenCmd)
+		if err != nil {
+			return nil, err
+		}
+		oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithOrgUrl(config.domain), oktaV4.WithToken(token), oktaV4.WithHttpClientPtr(httpClient), oktaV4.WithUserAgentExtra(config.userAgentExtra), oktaV4.WithRequestTimeout(config.callTimeout), oktaV4.WithRateLimitMaxRetries(0), oktaV4.WithRateLimitMaxBackOff(config.maxBackoff))
+		if err != nil {
+			return nil, err
+		}
+		return oktaV4.NewAPIClient(oktaConfiguratiopn), nil
 	}
 
-	if domain != "" && clientID != "" && privateKey != "" {
-		oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithOrgUrl(domain), oktaV4.WithAuthorizationMode("PrivateKey"), oktaV4.WithClientId(clientID), oktaV4.WithPrivateKey(privateKey), oktaV4.WithScopes(scopes), oktaV4.WithRequestTimeout(requestTimeout), oktaV4.WithRateLimitMaxRetries(maxRetries), oktaV4.WithRateLimitMaxBackOff(maxBackoff))
+	if config.domain != "" && config.accessToken != "" {
+		oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithOrgUrl(config.domain), oktaV4.WithAuthorizationMode("Bearer"), oktaV4.WithToken(config.accessToken), oktaV4.WithHttpClientPtr(httpClient), oktaV4.WithUserAgentExtra(config.userAgentExtra), oktaV4.WithRequestTimeout(config.callTimeout), oktaV4.WithRateLimitMaxRetries(0), oktaV4.WithRateLimitMaxBackOff(config.maxBackoff))
 		if err != nil {
 			return nil, err
 		}
+		return oktaV4.NewAPIClient(oktaConfiguratiopn), nil
+	}
 
-		client := oktaV4.NewAPIClient(oktaConfiguratiopn)
+	if config.domain != "" && config.clientID != "" && config.privateKey != "" {
+		oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithOrgUrl(config.domain), oktaV4.WithAuthorizationMode("PrivateKey"), oktaV4.WithClientId(config.clientID), oktaV4.WithPrivateKey(config.privateKey), oktaV4.WithPrivateKeyId(config.privateKeyID), oktaV4.WithScopes(config.getScopes(defaultScopesV4)), oktaV4.WithHttpClientPtr(httpClient), oktaV4.WithUserAgentExtra(config.userAgentExtra), oktaV4.WithRequestTimeout(config.callTimeout), oktaV4.WithRateLimitMaxRetries(0), oktaV4.WithRateLimitMaxBackOff(config.maxBackoff))
+		if err != nil {
+			return nil, err
+		}
 
-		d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-		return client, err
+		return oktaV4.NewAPIClient(oktaConfiguratiopn), nil
 	}
 
 	/* *
@@ -112,54 +222,71 @@ func ConnectV4(ctx context.Context, d *plugin.QueryData) (*oktaV4.APIClient, err
 	* 3. Environment variables
 	* 4. Configuration explicitly passed to the constructor (see the example in Getting started)
 	*	*/
-	oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithRequestTimeout(requestTimeout), oktaV4.WithRateLimitMaxRetries(maxRetries), oktaV4.WithRateLimitMaxBackOff(maxBackoff))
+	oktaConfiguratiopn, err := oktaV4.NewConfiguration(oktaV4.WithHttpClientPtr(httpClient), oktaV4.WithUserAgentExtra(config.userAgentExtra), oktaV4.WithRequestTimeout(config.callTimeout), oktaV4.WithRateLimitMaxRetries(0), oktaV4.WithRateLimitMaxBackOff(config.maxBackoff))
 	if err != nil {
 		return nil, err
 	}
-	client := oktaV4.NewAPIClient(oktaConfiguratiopn)
-
-	// Save session into cache
-	d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-
-	return client, err
+	return oktaV4.NewAPIClient(oktaConfiguratiopn), nil
 }
 
 func ConnectV5(ctx context.Context, d *plugin.QueryData) (*oktaV5.APIClient, error) {
-	// have we already created and cached the session?
-	sessionCacheKey := "OktaSessionV5"
-	if cachedData, ok := d.ConnectionManager.Cache.Get(sessionCacheKey); ok {
-		return cachedData.(*oktaV5.APIClient), nil
+	client, err := getOrCreateClient(ctx, d, "OktaSessionV5", func() (interface{}, error) {
+		return newOktaClientV5(d.Connection)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return client.(*oktaV5.APIClient), nil
+}
 
+func newOktaClientV5(connection *plugin.Connection) (*oktaV5.APIClient, error) {
 	// Get environment or steampipe config value
-	domain, token, clientID, privateKey, requestTimeout, maxBackoff, maxRetries, err := getOktaConfigValues(d)
+	config, err := getOktaConfigValues(connection)
 	if err != nil {
 		return nil, fmt.Errorf("error in retrieving config or environment values: %v", err)
 	}
+	// Rate limited requests are retried by the HTTP transport, so the SDK
+	// doesn't retry them again
+	httpClient, err := getHTTPClient(connection, config)
+	if err != nil {
+		return nil, err
+	}
 
-	scopes := []string{"okta.users.read", "okta.groups.read", "okta.roles.read", "okta.apps.read", "okta.policies.read", "okta.authorizationServers.read", "okta.trustedOrigins.read", "okta.factors.read", "okta.devices.read"}
-
-	if domain != "" && token != "" {
-		oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithOrgUrl(domain), oktaV5.WithToken(token), oktaV5.WithRequestTimeout(requestTimeout), oktaV5.WithRateLimitMaxRetries(maxRetries), oktaV5.WithRateLimitMaxBackOff(maxBackoff))
+	if config.domain != "" && config.token != "" {
+		oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithOrgUrl(config.domain), oktaV5.WithToken(config.token), oktaV5.WithHttpClientPtr(httpClient), oktaV5.WithUserAgentExtra(config.userAgentExtra), oktaV5.WithRequestTimeout(config.callTimeout), oktaV5.WithRateLimitMaxRetries(0), oktaV5.WithRateLimitMaxBackOff(config.maxBackoff))
 		if err != nil {
 			return nil, err
 		}
-		client := oktaV5.NewAPIClient(oktaConfiguratiopn)
+		return oktaV5.NewAPIClient(oktaConfiguratiopn), nil
+	}
 
-		d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-		return client, err
+	if config.domain != "" && config.tokenCmd != "" {
+		token, err := getCommandToken(config.tokenCmd)
+		if err != nil {
+			return nil, err
+		}
+		oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithOrgUrl(config.domain), oktaV5.WithToken(token), oktaV5.WithHttpClientPtr(httpClient), oktaV5.WithUserAgentExtra(config.userAgentExtra), oktaV5.WithRequestTimeout(config.callTimeout), oktaV5.WithRateLimitMaxRetries(0), oktaV5.WithRateLimitMaxBackOff(config.maxBackoff))
+		if err != nil {
+			return nil, err
+		}
+		return oktaV5.NewAPIClient(oktaConfiguratiopn), nil
 	}
 
-	if domain != "" && clientID != "" && privateKey != "" {
-		oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithOrgUrl(domain), oktaV5.WithAuthorizationMode("PrivateKey"), oktaV5.WithClientId(clientID), oktaV5.WithPrivateKey(privateKey), oktaV5.WithScopes(scopes), oktaV5.WithRequestTimeout(requestTimeout), oktaV5.WithRateLimitMaxRetries(maxRetries), oktaV5.WithRateLimitMaxBackOff(maxBackoff))
+	if config.domain != "" && config.accessToken != "" {
+		oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithOrgUrl(config.domain), oktaV5.WithAuthorizationMode("Bearer"), oktaV5.WithToken(config.accessToken), oktaV5.WithHttpClientPtr(httpClient), oktaV5.WithUserAgentExtra(config.userAgentExtra), oktaV5.WithRequestTimeout(config.callTimeout), oktaV5.WithRateLimitMaxRetries(0), oktaV5.WithRateLimitMaxBackOff(config.maxBackoff))
 		if err != nil {
 			return nil, err
 		}
+		return oktaV5.NewAPIClient(oktaConfiguratiopn), nil
+	}
 
-		client := oktaV5.NewAPIClient(oktaConfiguratiopn)
+	if config.domain != "" && config.clientID != "" && config.privateKey != "" {
+		oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithOrgUrl(config.domain), oktaV5.WithAuthorizationMode("PrivateKey"), oktaV5.WithClientId(config.clientID), oktaV5.WithPrivateKey(config.privateKey), oktaV5.WithPrivateKeyId(config.privateKeyID), oktaV5.WithScopes(config.getScopes(defaultScopesV4)), oktaV5.WithHttpClientPtr(httpClient), oktaV5.WithUserAgentExtra(config.userAgentExtra), oktaV5.WithRequestTimeout(config.callTimeout), oktaV5.WithRateLimitMaxRetries(0), oktaV5.WithRateLimitMaxBackOff(config.maxBackoff))
+		if err != nil {
+			return nil, err
+		}
 
-		d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-		return client, err
+		return oktaV5.NewAPIClient(oktaConfiguratiopn), nil
 	}
 
 	/* *
@@ -171,16 +298,11 @@ func ConnectV5(ctx context.Context, d *plugin.QueryData) (*oktaV5.APIClient, err
 	* 3. Environment variables
 	* 4. Configuration explicitly passed to the constructor (see the example in Getting started)
 	*	*/
-	oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithRequestTimeout(requestTimeout), oktaV5.WithRateLimitMaxRetries(maxRetries), oktaV5.WithRateLimitMaxBackOff(maxBackoff))
+	oktaConfiguratiopn, err := oktaV5.NewConfiguration(oktaV5.WithHttpClientPtr(httpClient), oktaV5.WithUserAgentExtra(config.userAgentExtra), oktaV5.WithRequestTimeout(config.callTimeout), oktaV5.WithRateLimitMaxRetries(0), oktaV5.WithRateLimitMaxBackOff(config.maxBackoff))
 	if err != nil {
 		return nil, err
 	}
-	client := oktaV5.NewAPIClient(oktaConfiguratiopn)
-
-	// Save session into cache
-	d.ConnectionManager.Cache.Set(sessionCacheKey, client)
-
-	return client, err
+	return oktaV5.NewAPIClient(oktaConfiguratiopn), nil
 }
 
 // Retrieves an int64 value from an environment variable, with proper error handling
@@ -209,41 +331,112 @@ func getEnvVarInt32(envVar string, defaultValue int32) (int32, error) {
 	return defaultValue, nil
 }
 
+// Scopes requested by service apps unless the connection sets its own. Only
+// the v4 and v5 clients read devices.
+var (
+	defaultScopes   = []string{"okta.users.read", "okta.groups.read", "okta.roles.read", "okta.apps.read", "okta.policies.read", "okta.authorizationServers.read", "okta.trustedOrigins.read", "okta.factors.read"}
+	defaultScopesV4 = []string{"okta.users.read", "okta.groups.read", "okta.roles.read", "okta.apps.read", "okta.policies.read", "okta.authorizationServers.read", "okta.trustedOrigins.read", "okta.factors.read", "okta.devices.read"}
+)
+
+// oktaClientConfig holds the resolved settings the Okta clients of a
+// connection are built with
+type oktaClientConfig struct {
+	domain         string
+	token          string
+	tokenCmd       string
+	accessToken    string
+	clientID       string
+	privateKey     string
+	privateKeyID   string
+	scopes         []string
+	dpop           bool
+	userAgentExtra string
+	requestTimeout int64
+	callTimeout    int64
+	maxBackoff     int64
+	maxRetries     int32
+}
+
 // Retrieve Okta configuration values
-func getOktaConfigValues(d *plugin.QueryData) (domain, token, clientID, privateKey string, requestTimeout, maxBackoff int64, maxRetries int32, err error) {
-	oktaConfig := GetConfig(d.Connection)
+func getOktaConfigValues(connection *plugin.Connection) (config oktaClientConfig, err error) {
+	oktaConfig := GetConfig(connection)
 
 	// The default value has been set as per the API doc: https://github.com/okta/okta-sdk-golang?tab=readme-ov-file#environment-variables
 	// SDK supported environment variables: https://github.com/okta/okta-sdk-golang/blob/master/okta/config.go#L33-L70
-	requestTimeout, err = getEnvVarInt64("OKTA_CLIENT_REQUEST_TIMEOUT", 30)
+	config.requestTimeout, err = getEnvVarInt64("OKTA_CLIENT_REQUEST_TIMEOUT", 30)
 	if err != nil {
-		return "", "", "", "", 0, 0, 0, err
+		return oktaClientConfig{}, err
 	}
-	maxBackoff, err = getEnvVarInt64("OKTA_CLIENT_RATE_LIMIT_MAX_BACKOFF", 30)
+	config.maxBackoff, err = getEnvVarInt64("OKTA_CLIENT_RATE_LIMIT_MAX_BACKOFF", 30)
 	if err != nil {
-		return "", "", "", "", 0, 0, 0, err
+		return oktaClientConfig{}, err
 	}
-	maxRetries, err = getEnvVarInt32("OKTA_CLIENT_RATE_LIMIT_MAX_RETRIES", 5)
+	config.maxRetries, err = getEnvVarInt32("OKTA_CLIENT_RATE_LIMIT_MAX_RETRIES", 5)
 	if err != nil {
-		return "", "", "", "", 0, 0, 0, err
+		return oktaClientConfig{}, err
 	}
 
 	if oktaConfig.MaxBackoff != nil {
-		maxBackoff = *oktaConfig.MaxBackoff
+		config.maxBackoff = *oktaConfig.MaxBackoff
 	}
 	if oktaConfig.RequestTimeout != nil {
-		requestTimeout = *oktaConfig.RequestTimeout
+		config.requestTimeout = *oktaConfig.RequestTimeout
 	}
 	if oktaConfig.MaxRetries != nil {
-		maxRetries = *oktaConfig.MaxRetries
+		config.maxRetries = *oktaConfig.MaxRetries
 	}
 
-	domain = getStringValue(oktaConfig.Domain, "OKTA_CLIENT_ORGURL")
-	token = getStringValue(oktaConfig.Token, "OKTA_CLIENT_TOKEN")
-	clientID = getStringValue(oktaConfig.ClientID, "OKTA_CLIENT_CLIENTID")
-	privateKey = getStringValue(oktaConfig.PrivateKey, "OKTA_CLIENT_PRIVATEKEY")
+	// Each attempt of an API call times out after request_timeout in the HTTP
+	// transport, which retries it, so the SDK clients only time a call out
+	// once all its attempts, backoffs and rate limit waits could have run
+	if config.requestTimeout > 0 && config.maxRetries > 0 {
+		config.callTimeout = (config.requestTimeout+config.maxBackoff)*int64(config.maxRetries+1) + int64(getMaxRateLimitWait(oktaConfig)/time.Second)
+	}
+
+	config.domain, err = normalizeOrgURL(getStringValue(oktaConfig.Domain, "OKTA_CLIENT_ORGURL"))
+	if err != nil {
+		return oktaClientConfig{}, err
+	}
+	config.token = getStringValue(oktaConfig.Token, "OKTA_CLIENT_TOKEN")
+	config.tokenCmd = getStringValue(oktaConfig.TokenCmd, "OKTA_CLIENT_TOKEN_CMD")
+	config.accessToken = getStringValue(oktaConfig.AccessToken, "OKTA_ACCESS_TOKEN")
+	config.clientID = getStringValue(oktaConfig.ClientID, "OKTA_CLIENT_CLIENTID")
+	var keyID string
+	config.privateKey, keyID, err = readPrivateKey(getStringValue(oktaConfig.PrivateKey, "OKTA_CLIENT_PRIVATEKEY"))
+	if err != nil {
+		return oktaClientConfig{}, err
+	}
+	// The key ID of a JWK is used unless set explicitly
+	config.privateKeyID = getStringValue(oktaConfig.PrivateKeyID, "OKTA_CLIENT_PRIVATEKEYID")
+	if config.privateKeyID == "" {
+		config.privateKeyID = keyID
+	}
 
-	return
+	config.scopes = getScopesValue(oktaConfig)
+	if oktaConfig.UserAgentExtra != nil {
+		config.userAgentExtra = *oktaConfig.UserAgentExtra
+	}
+	config.dpop = oktaConfig.DPoP != nil && *oktaConfig.DPoP
+
+	return config, nil
+}
+
+// getScopes returns the scopes set for the connection, or else the defaults
+// of the client
+func (c oktaClientConfig) getScopes(defaults []string) []string {
+	if len(c.scopes) > 0 {
+		return c.scopes
+	}
+	return defaults
+}
+
+// getScopesValue returns the scopes setting of the connection, or else the
+// comma separated scopes of the OKTA_CLIENT_SCOPES environment variable
+func getScopesValue(oktaConfig oktaConfig) []string {
+	if len(oktaConfig.Scopes) == 0 && os.Getenv("OKTA_CLIENT_SCOPES") != "" {
+		return strings.Split(os.Getenv("OKTA_CLIENT_SCOPES"), ",")
+	}
+	return oktaConfig.Scopes
 }
 
 func getStringValue(configValue *string, envVar string) string {
@@ -7,11 +7,39 @@ import (
 type oktaConfig struct {
 	Domain         *string `hcl:"domain"`
 	Token          *string `hcl:"token"`
+	TokenCmd       *string `hcl:"token_cmd"`
+	AccessToken    *string `hcl:"access_token"`
 	ClientID       *string `hcl:"client_id"`
 	PrivateKey     *string `hcl:"private_key"`
+	PrivateKeyID   *string `hcl:"private_key_id"`
+	DPoP           *bool   `hcl:"dpop"`
 	RequestTimeout *int64  `hcl:"request_timeout"`
 	MaxRetries     *int32  `hcl:"max_retries"`
 	MaxBackoff     *int64  `hcl:"max_backoff"`
+	UserAgentExtra *string `hcl:"user_agent_extra"`
+
+	MaxRateLimitWait      *int64 `hcl:"max_rate_limit_wait"`
+	RateLimitThreshold    *int   `hcl:"rate_limit_threshold"`
+	MaxPageSize           *int64 `hcl:"max_page_size"`
+	FactorListConcurrency *int   `hcl:"factor_list_concurrency"`
+
+	CircuitBreakerThreshold *int    `hcl:"circuit_breaker_threshold"`
+	CircuitBreakerCooldown  *int64  `hcl:"circuit_breaker_cooldown"`
+	DialTimeout             *int64  `hcl:"dial_timeout"`
+	TLSHandshakeTimeout     *int64  `hcl:"tls_handshake_timeout"`
+	ProxyURL                *string `hcl:"proxy_url"`
+	HTTPLogLevel            *string `hcl:"http_log_level"`
+	AllowPartialResults     *bool   `hcl:"allow_partial_results"`
+	StrictPermissions       *bool   `hcl:"strict_permissions"`
+
+	MaxConcurrency      *int           `hcl:"max_concurrency"`
+	TableMaxConcurrency map[string]int `hcl:"table_max_concurrency,optional"`
+
+	Scopes              []string `hcl:"scopes,optional"`
+	RetryOn             []string `hcl:"retry_on,optional"`
+	IgnoreErrorCodes    []string `hcl:"ignore_error_codes,optional"`
+	AppUserSchemaAppIds []string `hcl:"app_user_schema_app_ids,optional"`
+	DisabledColumns     []string `hcl:"disabled_columns,optional"`
 }
 
 func ConfigInstance() interface{} {
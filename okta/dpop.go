@@ -0,0 +1,291 @@
+package okta
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// dpopTransport sender-constrains the access tokens of a service app that
+// requires DPoP (Demonstrating Proof of Possession) to a key pair generated
+// for the connection. A DPoP proof is sent with the token requests of the SDK
+// clients, and the DPoP-bound tokens returned are handed to them as Bearer
+// tokens, so the v2, v4 and v5 clients all use them as usual. Requests sent
+// with one of these tokens are then switched to the DPoP scheme with a proof
+// of their own.
+type dpopTransport struct {
+	base     http.RoundTripper
+	clientID string
+
+	// Signs the DPoP proofs with the generated key, embedding its public key
+	proofSigner jose.Signer
+	// Signs the client assertions of token requests retried with a nonce
+	assertionSigner jose.Signer
+
+	mu         sync.Mutex
+	tokenNonce string
+	apiNonce   string
+	tokens     map[string]time.Time
+}
+
+type dpopProofClaims struct {
+	ID              string           `json:"jti"`
+	Method          string           `json:"htm"`
+	URL             string           `json:"htu"`
+	IssuedAt        *jwt.NumericDate `json:"iat"`
+	Nonce           string           `json:"nonce,omitempty"`
+	AccessTokenHash string           `json:"ath,omitempty"`
+}
+
+func newDPoPTransport(base http.RoundTripper, clientID, privateKey, privateKeyID string) (*dpopTransport, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	proofSigner, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt"))
+	if err != nil {
+		return nil, err
+	}
+	assertionSigner, err := newPrivateKeySigner(privateKey, privateKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dpopTransport{
+		base:            base,
+		clientID:        clientID,
+		proofSigner:     proofSigner,
+		assertionSigner: assertionSigner,
+		tokens:          map[string]time.Time{},
+	}, nil
+}
+
+func (t *dpopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/oauth2/v1/token") {
+		return t.roundTripToken(req)
+	}
+	if token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "); t.isBound(token) {
+		return t.roundTripAPI(req, token)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// roundTripToken requests an access token with a DPoP proof. Okta asks for a
+// server provided nonce in the proof the first time, in which case the
+// request is sent again with the nonce and a new client assertion, as Okta
+// doesn't accept the same assertion twice.
+func (t *dpopTransport) roundTripToken(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	tokenReq := req.Clone(req.Context())
+	for attempt := 1; ; attempt++ {
+		proof, err := t.newProof(tokenReq.Method, tokenReq.URL, t.nonce(&t.tokenNonce), "")
+		if err != nil {
+			return nil, err
+		}
+		tokenReq.Header.Set("DPoP", proof)
+		if body != nil {
+			tokenReq.Body = io.NopCloser(bytes.NewReader(body))
+			tokenReq.ContentLength = int64(len(body))
+		}
+
+		resp, err := t.base.RoundTrip(tokenReq)
+		if err != nil {
+			return nil, err
+		}
+		t.saveNonce(&t.tokenNonce, resp)
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return t.bindToken(resp, respBody), nil
+		case attempt == 1 && resp.StatusCode == http.StatusBadRequest && resp.Header.Get("DPoP-Nonce") != "" && bytes.Contains(respBody, []byte("use_dpop_nonce")):
+			audience := tokenReq.URL.Scheme + "://" + tokenReq.URL.Host + tokenReq.URL.Path
+			tokenReq = req.Clone(req.Context())
+			// The v2 client sends the token request parameters in the query
+			// string and the v4 and v5 clients in the body
+			query := tokenReq.URL.Query()
+			if err := t.renewClientAssertion(query, audience); err != nil {
+				return nil, err
+			}
+			tokenReq.URL.RawQuery = query.Encode()
+			if body != nil {
+				form, err := url.ParseQuery(string(body))
+				if err != nil {
+					return nil, err
+				}
+				if err := t.renewClientAssertion(form, audience); err != nil {
+					return nil, err
+				}
+				body = []byte(form.Encode())
+			}
+		default:
+			return resp, nil
+		}
+	}
+}
+
+// roundTripAPI sends a request with a DPoP-bound access token, retrying it
+// once with the nonce Okta asks for if any
+func (t *dpopTransport) roundTripAPI(req *http.Request, token string) (*http.Response, error) {
+	apiReq := req.Clone(req.Context())
+	for attempt := 1; ; attempt++ {
+		proof, err := t.newProof(apiReq.Method, apiReq.URL, t.nonce(&t.apiNonce), token)
+		if err != nil {
+			return nil, err
+		}
+		apiReq.Header.Set("Authorization", "DPoP "+token)
+		apiReq.Header.Set("DPoP", proof)
+
+		resp, err := t.base.RoundTrip(apiReq)
+		if err != nil {
+			return nil, err
+		}
+		t.saveNonce(&t.apiNonce, resp)
+
+		if attempt > 1 || resp.StatusCode != http.StatusUnauthorized || !strings.Contains(resp.Header.Get("WWW-Authenticate"), "use_dpop_nonce") {
+			return resp, nil
+		}
+
+		// Requests with a body can only be sent again if the body can be read again
+		retry := req.Clone(req.Context())
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			if retry.Body, err = req.GetBody(); err != nil {
+				return resp, nil
+			}
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		apiReq = retry
+	}
+}
+
+// bindToken remembers the access token of a successful token response if it
+// is DPoP-bound, and returns the response with the token type set to Bearer
+func (t *dpopTransport) bindToken(resp *http.Response, body []byte) *http.Response {
+	var token map[string]interface{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return resp
+	}
+	accessToken, _ := token["access_token"].(string)
+	if tokenType, _ := token["token_type"].(string); accessToken == "" || !strings.EqualFold(tokenType, "DPoP") {
+		return resp
+	}
+
+	expiresIn := time.Hour
+	if seconds, ok := token["expires_in"].(float64); ok {
+		expiresIn = time.Duration(seconds) * time.Second
+	}
+	now := time.Now()
+	t.mu.Lock()
+	for bound, expiry := range t.tokens {
+		if now.After(expiry) {
+			delete(t.tokens, bound)
+		}
+	}
+	t.tokens[accessToken] = now.Add(expiresIn)
+	t.mu.Unlock()
+
+	token["token_type"] = "Bearer"
+	rewritten, err := json.Marshal(token)
+	if err != nil {
+		return resp
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(rewritten))
+	resp.ContentLength = int64(len(rewritten))
+	resp.Header.Del("Content-Length")
+	return resp
+}
+
+func (t *dpopTransport) isBound(token string) bool {
+	if token == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.tokens[token]
+	return ok
+}
+
+func (t *dpopTransport) nonce(nonce *string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *nonce
+}
+
+func (t *dpopTransport) saveNonce(nonce *string, resp *http.Response) {
+	if value := resp.Header.Get("DPoP-Nonce"); value != "" {
+		t.mu.Lock()
+		*nonce = value
+		t.mu.Unlock()
+	}
+}
+
+// newProof returns the DPoP proof of a request, bound to the access token sent
+// with it if any
+func (t *dpopTransport) newProof(method string, requestURL *url.URL, nonce, accessToken string) (string, error) {
+	claims := dpopProofClaims{
+		ID:       uuid.New().String(),
+		Method:   method,
+		URL:      requestURL.Scheme + "://" + requestURL.Host + requestURL.Path,
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Nonce:    nonce,
+	}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims.AccessTokenHash = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+	return jwt.Signed(t.proofSigner).Claims(claims).CompactSerialize()
+}
+
+// renewClientAssertion replaces the client assertion of token request
+// parameters with a new one
+func (t *dpopTransport) renewClientAssertion(params url.Values, audience string) error {
+	if params.Get("client_assertion") == "" {
+		return nil
+	}
+	now := time.Now()
+	claims := jwt.Claims{
+		Issuer:   t.clientID,
+		Subject:  t.clientID,
+		Audience: jwt.Audience{audience},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+		ID:       uuid.New().String(),
+	}
+	assertion, err := jwt.Signed(t.assertionSigner).Claims(claims).CompactSerialize()
+	if err != nil {
+		return err
+	}
+	params.Set("client_assertion", assertion)
+	return nil
+}
@@ -0,0 +1,226 @@
+package okta
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v2/okta"
+	oktaV4 "github.com/okta/okta-sdk-golang/v4/okta"
+	oktaV5 "github.com/okta/okta-sdk-golang/v5/okta"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// Okta error codes and HTTP status code of requests for missing resources
+var notFoundErrorCodes = []string{"E0000007", "E0000008", "404"}
+
+// Okta error codes and HTTP status code of requests denied for a missing
+// admin role or OAuth scope
+var forbiddenErrorCodes = []string{"E0000006", "E0000015", "403"}
+
+// HTTP status codes of the Okta error codes the v2 SDK errors only carry
+var oktaErrorStatusCodes = map[string]string{
+	"E0000006": "403",
+	"E0000007": "404",
+	"E0000008": "404",
+	"E0000011": "401",
+	"E0000015": "403",
+	"E0000047": "429",
+}
+
+// isNotFoundError returns whether an error of any of the SDK clients is
+// returned for a missing resource
+func isNotFoundError(err error) bool {
+	return hasErrorCode(err, notFoundErrorCodes)
+}
+
+// shouldIgnoreNotFoundError ignores the errors of get calls for missing
+// resources, along with those of the ignore_error_codes setting
+func shouldIgnoreNotFoundError() plugin.ErrorPredicateWithContext {
+	ignoreErrorCodes := shouldIgnoreErrorPluginDefault()
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+		return isNotFoundError(err) || ignoreErrorCodes(ctx, d, h, err)
+	}
+}
+
+// shouldIgnoreFactorNotFoundError ignores the errors of factor get calls for
+// missing factors, which are reported either as not found or, for IDs that
+// aren't factors of the user, as an "Invalid Factor" error
+func shouldIgnoreFactorNotFoundError() plugin.ErrorPredicateWithContext {
+	ignoreNotFound := shouldIgnoreNotFoundError()
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+		return isInvalidFactorError(err) || ignoreNotFound(ctx, d, h, err)
+	}
+}
+
+// isInvalidFactorError returns whether an error is the "Invalid Factor" error
+// of the factor APIs. Its summary is only in the body of the v4 and v5 errors.
+func isInvalidFactorError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if strings.Contains(err.Error(), "Invalid Factor") {
+		return true
+	}
+	var v4Err *oktaV4.GenericOpenAPIError
+	if errors.As(err, &v4Err) && strings.Contains(string(v4Err.Body()), "Invalid Factor") {
+		return true
+	}
+	var v5Err *oktaV5.GenericOpenAPIError
+	return errors.As(err, &v5Err) && strings.Contains(string(v5Err.Body()), "Invalid Factor")
+}
+
+// shouldIgnoreErrorPluginDefault ignores the errors whose Okta error code,
+// e.g. E0000006, or HTTP status code, e.g. 403, is listed in the
+// ignore_error_codes setting of the connection, so the tables and columns a
+// limited admin role can't read return no rows or null instead of failing
+func shouldIgnoreErrorPluginDefault() plugin.ErrorPredicateWithContext {
+	return func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+		return hasErrorCode(err, GetConfig(d.Connection).IgnoreErrorCodes)
+	}
+}
+
+// shouldIgnoreForbiddenColumn ignores the errors of a column hydrate function
+// denied access, along with those ignored by base or, by default, the
+// ignore_error_codes setting. The first denied call of a query logs a warning.
+func shouldIgnoreForbiddenColumn(table string, columns []string, base *plugin.IgnoreConfig) *plugin.IgnoreConfig {
+	shouldIgnore := shouldIgnoreErrorPluginDefault()
+	if base != nil && base.ShouldIgnoreErrorFunc != nil {
+		shouldIgnore = base.ShouldIgnoreErrorFunc
+	}
+	return &plugin.IgnoreConfig{
+		ShouldIgnoreErrorFunc: func(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, err error) bool {
+			if shouldIgnore(ctx, d, h, err) {
+				return true
+			}
+			if !hasErrorCode(err, forbiddenErrorCodes) {
+				return false
+			}
+			if forbiddenWarnings.first(d.QueryContext, table, columns) {
+				plugin.Logger(ctx).Warn("shouldIgnoreForbiddenColumn", "table", table, "columns", columns, "returning_null", err)
+			}
+			return true
+		},
+	}
+}
+
+// forbiddenWarnings tracks the columns already reported as denied by each
+// query, so that the warning is not logged for every row
+var forbiddenWarnings = &warningSet{seen: map[warningKey]time.Time{}}
+
+// Time after which a query is assumed to be over and its warnings forgotten
+const warningRetention = time.Hour
+
+type warningKey struct {
+	query  *plugin.QueryContext
+	table  string
+	column string
+}
+
+type warningSet struct {
+	mu   sync.Mutex
+	seen map[warningKey]time.Time
+}
+
+// first returns whether the columns are reported for the first time in the
+// query, forgetting the queries that started more than warningRetention ago
+func (s *warningSet) first(query *plugin.QueryContext, table string, columns []string) bool {
+	key := warningKey{query: query, table: table}
+	if len(columns) > 0 {
+		key.column = columns[0]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, seen := range s.seen {
+		if now.Sub(seen) > warningRetention {
+			delete(s.seen, k)
+		}
+	}
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = now
+	return true
+}
+
+func hasErrorCode(err error, codes []string) bool {
+	if err == nil || len(codes) == 0 {
+		return false
+	}
+	for _, code := range errorCodes(err) {
+		if slices.Contains(codes, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError adds the HTTP status code of the response to an error of the
+// v2 SDK client, whose errors only carry the Okta error code if any
+type statusError struct {
+	err        error
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// withStatusCode returns the error of a v2 SDK call with the status code of
+// its response
+func withStatusCode(err error, resp *okta.Response) error {
+	if err == nil || resp == nil || resp.Response == nil {
+		return err
+	}
+	return &statusError{err: err, statusCode: resp.StatusCode}
+}
+
+// errorCodes returns the Okta error code and HTTP status code of an error
+// returned by any of the SDK clients, as far as they are known
+func errorCodes(err error) []string {
+	var codes []string
+
+	var withStatus *statusError
+	if errors.As(err, &withStatus) {
+		codes = append(codes, strconv.Itoa(withStatus.statusCode))
+	}
+
+	var v2Err *okta.Error
+	if errors.As(err, &v2Err) && v2Err.ErrorCode != "" {
+		codes = append(codes, v2Err.ErrorCode)
+		if status, ok := oktaErrorStatusCodes[v2Err.ErrorCode]; ok {
+			codes = append(codes, status)
+		}
+	}
+
+	// The v4 and v5 errors hold the response status, e.g. "403 Forbidden",
+	// and the Okta error of some status codes
+	var v4Err *oktaV4.GenericOpenAPIError
+	if errors.As(err, &v4Err) {
+		status, _, _ := strings.Cut(v4Err.Error(), " ")
+		codes = append(codes, status)
+		if model, ok := v4Err.Model().(oktaV4.Error); ok {
+			codes = append(codes, model.GetErrorCode())
+		}
+	}
+	var v5Err *oktaV5.GenericOpenAPIError
+	if errors.As(err, &v5Err) {
+		status, _, _ := strings.Cut(v5Err.Error(), " ")
+		codes = append(codes, status)
+		if model, ok := v5Err.Model().(oktaV5.Error); ok {
+			codes = append(codes, model.GetErrorCode())
+		}
+	}
+
+	return codes
+}
@@ -0,0 +1,135 @@
+package okta
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+// Levels of the http_log_level setting
+const (
+	httpLogOff   = "off"
+	httpLogBasic = "basic"
+	httpLogFull  = "full"
+)
+
+var httpLogLevels = []string{httpLogOff, httpLogBasic, httpLogFull}
+
+// Headers and query parameters holding credentials, which are never logged
+var (
+	redactedHeaders     = []string{"Authorization", "Dpop", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	redactedQueryParams = []string{"access_token", "assertion", "client_assertion", "client_secret", "id_token", "sessionToken", "token"}
+)
+
+// Response headers logged at the basic level
+var loggedResponseHeaders = []string{"X-Okta-Request-Id", "X-Rate-Limit-Limit", "X-Rate-Limit-Remaining", "X-Rate-Limit-Reset"}
+
+// loggingTransport logs each request sent to Okta once its response has been
+// read, with its duration, the size of the response and the rate limit
+// headers. The full level also logs all request and response headers.
+// Credentials in headers and query strings are redacted.
+type loggingTransport struct {
+	base  http.RoundTripper
+	level string
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.log(req, "okta_http_request", "method", req.Method, "url", redactURL(req.URL), "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+
+	resp.Body = &loggedBody{ReadCloser: resp.Body, onClose: func(size int64) {
+		args := []interface{}{"method", req.Method, "url", redactURL(req.URL), "status", resp.StatusCode, "duration", time.Since(start), "response_size", size}
+		for _, header := range loggedResponseHeaders {
+			if value := resp.Header.Get(header); value != "" {
+				args = append(args, strings.ToLower(header), value)
+			}
+		}
+		if t.level == httpLogFull {
+			args = append(args, "request_headers", redactHeaders(req.Header), "response_headers", redactHeaders(resp.Header))
+		}
+		t.log(req, "okta_http_request", args...)
+	}}
+	return resp, nil
+}
+
+// log writes to the logger of the query when the request has one, e.g. not
+// for the token requests the SDKs send without the query context
+func (t *loggingTransport) log(req *http.Request, msg string, args ...interface{}) {
+	if logger, ok := req.Context().Value(context_key.Logger).(interface{ Info(string, ...interface{}) }); ok {
+		logger.Info(msg, args...)
+		return
+	}
+	pairs := make([]string, 0, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+	}
+	log.Printf("[INFO] %s: %s", msg, strings.Join(pairs, " "))
+}
+
+// loggedBody counts the bytes read from a response body and reports them
+// once the body is closed
+type loggedBody struct {
+	io.ReadCloser
+	size    int64
+	onClose func(size int64)
+	closed  bool
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.onClose(b.size)
+	}
+	return err
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for _, param := range redactedQueryParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+func redactHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var values []string
+	for _, name := range names {
+		value := strings.Join(header.Values(name), ", ")
+		for _, redacted := range redactedHeaders {
+			if strings.EqualFold(name, redacted) {
+				value = "REDACTED"
+			}
+		}
+		values = append(values, name+": "+value)
+	}
+	return strings.Join(values, "; ")
+}
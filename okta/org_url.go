@@ -0,0 +1,67 @@
+package okta
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Domains of the Okta cells orgs are hosted in, whose org URLs must include
+// the org subdomain. Other domains are custom domains of an org.
+var oktaCellDomains = []string{
+	"okta.com",
+	"oktapreview.com",
+	"okta-emea.com",
+	"okta-gov.com",
+	"okta.mil",
+}
+
+var hostnamePattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}$`)
+
+// normalizeOrgURL validates the domain setting of a connection and returns it
+// as the https://<host> org URL the SDK clients expect. The scheme may be
+// left out, trailing slashes are removed, and admin console URLs are turned
+// into the org URL, e.g. acme-admin.okta.com into https://acme.okta.com.
+func normalizeOrgURL(domain string) (string, error) {
+	domain = strings.TrimRight(strings.TrimSpace(domain), "/")
+	if domain == "" {
+		return "", nil
+	}
+	if strings.Contains(domain, "{") {
+		return "", fmt.Errorf("invalid domain %q: replace the placeholder with the URL of your Okta org, e.g. https://acme.okta.com", domain)
+	}
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+
+	orgURL, err := url.Parse(domain)
+	if err != nil {
+		return "", fmt.Errorf("invalid domain %q: %v", domain, err)
+	}
+	if orgURL.Scheme != "https" {
+		return "", fmt.Errorf("invalid domain %q: Okta orgs are only reachable over https", domain)
+	}
+	if orgURL.Path != "" || orgURL.RawQuery != "" || orgURL.User != nil {
+		return "", fmt.Errorf("invalid domain %q: only the URL of the org is expected, e.g. https://%s", domain, orgURL.Host)
+	}
+
+	host := strings.ToLower(orgURL.Hostname())
+	if !hostnamePattern.MatchString(host) {
+		return "", fmt.Errorf("invalid domain %q: %q is not a valid host name", domain, host)
+	}
+	for _, cellDomain := range oktaCellDomains {
+		if host == cellDomain {
+			return "", fmt.Errorf("invalid domain %q: the org subdomain is missing, e.g. https://acme.%s", domain, cellDomain)
+		}
+		if subdomain, ok := strings.CutSuffix(host, "."+cellDomain); ok {
+			host = strings.TrimSuffix(subdomain, "-admin") + "." + cellDomain
+			break
+		}
+	}
+
+	if port := orgURL.Port(); port != "" {
+		host += ":" + port
+	}
+	return "https://" + host, nil
+}
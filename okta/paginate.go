@@ -0,0 +1,139 @@
+package okta
+
+import (
+	"context"
+
+	"github.com/okta/okta-sdk-golang/v2/okta"
+	oktaV4 "github.com/okta/okta-sdk-golang/v4/okta"
+	oktaV5 "github.com/okta/okta-sdk-golang/v5/okta"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// pager fetches the pages following the first page of a list call, whichever
+// SDK client made it
+type pager[T any] struct {
+	hasNext func() bool
+	next    func() ([]T, error)
+}
+
+// v2Pages follows the next page links of a list call of the v2 client
+func v2Pages[T any](ctx context.Context, resp *okta.Response) *pager[T] {
+	return &pager[T]{
+		hasNext: func() bool { return resp != nil && resp.HasNextPage() },
+		next: func() ([]T, error) {
+			var items []T
+			nextResp, err := resp.Next(ctx, &items)
+			if err != nil {
+				return nil, withStatusCode(err, nextResp)
+			}
+			resp = nextResp
+			return items, nil
+		},
+	}
+}
+
+// v4Pages follows the next page links of a list call of the v4 client
+func v4Pages[T any](resp *oktaV4.APIResponse) *pager[T] {
+	return &pager[T]{
+		hasNext: func() bool { return resp != nil && resp.HasNextPage() },
+		next: func() ([]T, error) {
+			var items []T
+			nextResp, err := resp.Next(&items)
+			if err != nil {
+				return nil, err
+			}
+			resp = nextResp
+			return items, nil
+		},
+	}
+}
+
+// v5Pages follows the next page links of a list call of the v5 client
+func v5Pages[T any](resp *oktaV5.APIResponse) *pager[T] {
+	return &pager[T]{
+		hasNext: func() bool { return resp != nil && resp.HasNextPage() },
+		next: func() ([]T, error) {
+			var items []T
+			nextResp, err := resp.Next(&items)
+			if err != nil {
+				return nil, err
+			}
+			resp = nextResp
+			return items, nil
+		},
+	}
+}
+
+// urlPages follows the next page links of a list call of the v2 client whose
+// pages are fetched by URL, e.g. through the list page cache
+func urlPages[T any](resp *okta.Response, fetch func(url string) ([]T, *okta.Response, error)) *pager[T] {
+	return &pager[T]{
+		hasNext: func() bool { return resp != nil && resp.HasNextPage() },
+		next: func() ([]T, error) {
+			items, nextResp, err := fetch(resp.NextPage)
+			if err != nil {
+				return nil, err
+			}
+			resp = nextResp
+			return items, nil
+		},
+	}
+}
+
+// forEachPage calls fn with the items of the first page, then with those of
+// each page following it until fn returns false. A page that fails after the
+// transport retries is requested again, see retryListPage.
+func forEachPage[T any](ctx context.Context, items []T, pages *pager[T], fn func([]T) bool) error {
+	for fn(items) && pages.hasNext() {
+		err := retryListPage(ctx, func() (err error) {
+			items, err = pages.next()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamPages streams the row of each item of a list, as returned by row,
+// until the limit of the query is reached. Items whose row is nil are
+// skipped, and a nil row func streams the items themselves. When a page fails
+// mid-way through the list, the rows already streamed are kept if the
+// connection allows partial results, see pagingError.
+func streamPages[T any](ctx context.Context, d *plugin.QueryData, items []T, pages *pager[T], row func(T) interface{}) error {
+	err := forEachPage(ctx, items, pages, func(page []T) bool {
+		for _, item := range page {
+			var r interface{} = item
+			if row != nil {
+				if r = row(item); r == nil {
+					continue
+				}
+			}
+			d.StreamListItem(ctx, r)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return pagingError(ctx, d, err)
+	}
+	return nil
+}
+
+// collectPages returns the items of every page of a list
+func collectPages[T any](ctx context.Context, items []T, pages *pager[T]) ([]T, error) {
+	all := make([]T, 0, len(items))
+	err := forEachPage(ctx, items, pages, func(page []T) bool {
+		all = append(all, page...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
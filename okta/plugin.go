@@ -2,7 +2,9 @@ package okta
 
 import (
 	"context"
+	"slices"
 
+	"github.com/turbot/go-kit/helpers"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
@@ -23,28 +25,170 @@ func Plugin(ctx context.Context) *plugin.Plugin {
 		ConnectionConfigSchema: &plugin.ConnectionConfigSchema{
 			NewInstance: ConfigInstance,
 		},
-		TableMap: map[string]*plugin.Table{
-			"okta_app_assigned_group":    tableOktaApplicationAssignedGroup(),
-			"okta_app_assigned_user":     tableOktaApplicationAssignedUser(),
-			"okta_application":           tableOktaApplication(),
-			"okta_auth_server":           tableOktaAuthServer(),
-			"okta_authentication_policy": tableOktaAuthenticationPolicy(),
-			"okta_authenticator":         tableOktaAuthenticator(),
-			"okta_device":                tableOktaDevice(),
-			"okta_factor":                tableOktaFactor(),
-			"okta_group":                 tableOktaGroup(),
-			"okta_group_owner":           tableOktaGroupOwner(),
-			"okta_group_rule":            tableOktaGroupRule(),
-			"okta_idp_discovery_policy":  tableOktaIdpDiscoveryPolicy(),
-			"okta_mfa_policy":            tableOktaMfaPolicy(),
-			"okta_network_zone":          tableOktaNetworkZone(),
-			"okta_password_policy":       tableOktaPasswordPolicy(),
-			"okta_signon_policy":         tableOktaSignonPolicy(),
-			"okta_trusted_origin":        tableOktaTrustedOrigin(),
-			"okta_user":                  tableOktaUser(),
-			"okta_user_type":             tableOktaUserType(),
+		DefaultIgnoreConfig: &plugin.IgnoreConfig{
+			ShouldIgnoreErrorFunc: shouldIgnoreErrorPluginDefault(),
 		},
+		SchemaMode:   plugin.SchemaModeDynamic,
+		TableMapFunc: pluginTableDefinitions,
 	}
 
 	return p
 }
+
+// pluginTableDefinitions builds the tables for a connection. The okta_user
+// columns depend on the profile attributes defined in the org's user schema.
+func pluginTableDefinitions(ctx context.Context, td *plugin.TableMapData) (map[string]*plugin.Table, error) {
+	tables := map[string]*plugin.Table{
+		"okta_access_request_condition":  tableOktaAccessRequestCondition(),
+		"okta_app_assigned_group":        tableOktaApplicationAssignedGroup(),
+		"okta_app_assigned_user":         tableOktaApplicationAssignedUser(ctx, td),
+		"okta_application":               tableOktaApplication(),
+		"okta_auth_server":               tableOktaAuthServer(),
+		"okta_authentication_policy":     tableOktaAuthenticationPolicy(),
+		"okta_authenticator":             tableOktaAuthenticator(),
+		"okta_certificate":               tableOktaCertificate(),
+		"okta_connection_info":           tableOktaConnectionInfo(),
+		"okta_device":                    tableOktaDevice(),
+		"okta_factor":                    tableOktaFactor(),
+		"okta_group":                     tableOktaGroup(ctx, td),
+		"okta_group_owner":               tableOktaGroupOwner(),
+		"okta_governance_grant":          tableOktaGovernanceGrant(),
+		"okta_governance_resource_owner": tableOktaGovernanceResourceOwner(),
+		"okta_group_rule":                tableOktaGroupRule(),
+		"okta_idp_discovery_policy":      tableOktaIdpDiscoveryPolicy(),
+		"okta_mfa_policy":                tableOktaMfaPolicy(),
+		"okta_network_zone":              tableOktaNetworkZone(),
+		"okta_password_policy":           tableOktaPasswordPolicy(),
+		"okta_profile_enrollment_policy": tableOktaProfileEnrollmentPolicy(),
+		"okta_signon_policy":             tableOktaSignonPolicy(),
+		"okta_trusted_origin":            tableOktaTrustedOrigin(),
+		"okta_user":                      tableOktaUser(ctx, td),
+		"okta_user_group":                tableOktaUserGroup(),
+		"okta_user_mfa_summary":          tableOktaUserMfaSummary(),
+		"okta_user_type":                 tableOktaUserType(),
+	}
+
+	applyHydrateConcurrency(tables, GetConfig(td.Connection))
+	disableHydrateColumns(tables, GetConfig(td.Connection))
+	degradeForbiddenColumns(tables, GetConfig(td.Connection))
+
+	return tables, nil
+}
+
+// applyHydrateConcurrency overrides the number of concurrent calls of the
+// column hydrate functions of each table with the max_concurrency and
+// table_max_concurrency settings of the connection
+func applyHydrateConcurrency(tables map[string]*plugin.Table, config oktaConfig) {
+	for name, table := range tables {
+		maxConcurrency := 0
+		if config.MaxConcurrency != nil {
+			maxConcurrency = *config.MaxConcurrency
+		}
+		if tableMaxConcurrency, ok := config.TableMaxConcurrency[name]; ok {
+			maxConcurrency = tableMaxConcurrency
+		}
+		if maxConcurrency <= 0 {
+			continue
+		}
+
+		configured := map[string]bool{}
+		for i := range table.HydrateConfig {
+			table.HydrateConfig[i].MaxConcurrency = maxConcurrency
+			configured[helpers.GetFunctionName(table.HydrateConfig[i].Func)] = true
+		}
+		for _, column := range table.Columns {
+			if column.Hydrate == nil {
+				continue
+			}
+			funcName := helpers.GetFunctionName(column.Hydrate)
+			if configured[funcName] {
+				continue
+			}
+			configured[funcName] = true
+			table.HydrateConfig = append(table.HydrateConfig, plugin.HydrateConfig{Func: column.Hydrate, MaxConcurrency: maxConcurrency})
+		}
+	}
+}
+
+// disableHydrateColumns stops the columns listed in the disabled_columns
+// setting of the connection from making API calls, e.g. for dashboards running
+// select * on large orgs. Only columns fetched by their own hydrate function can
+// be disabled; they are returned as null. The SDK only calls the hydrate
+// functions of the selected columns, so the setting only matters for columns
+// that are selected.
+func disableHydrateColumns(tables map[string]*plugin.Table, config oktaConfig) {
+	if len(config.DisabledColumns) == 0 {
+		return
+	}
+
+	for name, table := range tables {
+		for _, column := range table.Columns {
+			if column.Hydrate == nil {
+				continue
+			}
+			if !slices.Contains(config.DisabledColumns, name+"."+column.Name) && !slices.Contains(config.DisabledColumns, name+".*") {
+				continue
+			}
+			column.Hydrate = disabledColumnHydrate
+			column.Transform = transform.FromValue()
+		}
+	}
+}
+
+// degradeForbiddenColumns returns null for the columns whose hydrate function
+// is denied access, e.g. the roles of a group when the API token lacks the
+// admin role or the OAuth app lacks the scope, instead of failing the query. A
+// warning naming the columns is logged once per query. The strict_permissions
+// setting keeps the errors. List and get calls are not affected.
+func degradeForbiddenColumns(tables map[string]*plugin.Table, config oktaConfig) {
+	if config.StrictPermissions != nil && *config.StrictPermissions {
+		return
+	}
+
+	for name, table := range tables {
+		fetchFuncs := map[string]bool{}
+		if table.List != nil && table.List.Hydrate != nil {
+			fetchFuncs[helpers.GetFunctionName(table.List.Hydrate)] = true
+		}
+		if table.Get != nil && table.Get.Hydrate != nil {
+			fetchFuncs[helpers.GetFunctionName(table.Get.Hydrate)] = true
+		}
+
+		columns := map[string][]string{}
+		hydrates := map[string]plugin.HydrateFunc{}
+		for _, column := range table.Columns {
+			if column.Hydrate == nil {
+				continue
+			}
+			funcName := helpers.GetFunctionName(column.Hydrate)
+			if fetchFuncs[funcName] || funcName == helpers.GetFunctionName(disabledColumnHydrate) {
+				continue
+			}
+			columns[funcName] = append(columns[funcName], column.Name)
+			hydrates[funcName] = column.Hydrate
+		}
+
+		configured := map[string]bool{}
+		for i := range table.HydrateConfig {
+			funcName := helpers.GetFunctionName(table.HydrateConfig[i].Func)
+			if _, ok := columns[funcName]; !ok {
+				continue
+			}
+			configured[funcName] = true
+			table.HydrateConfig[i].IgnoreConfig = shouldIgnoreForbiddenColumn(name, columns[funcName], table.HydrateConfig[i].IgnoreConfig)
+		}
+		for funcName, hydrate := range hydrates {
+			if configured[funcName] {
+				continue
+			}
+			table.HydrateConfig = append(table.HydrateConfig, plugin.HydrateConfig{
+				Func:         hydrate,
+				IgnoreConfig: shouldIgnoreForbiddenColumn(name, columns[funcName], nil),
+			})
+		}
+	}
+}
+
+func disabledColumnHydrate(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return nil, nil
+}
@@ -0,0 +1,102 @@
+package okta
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// readPrivateKey returns the PEM encoded private key of the service app, and
+// the key ID it is exported with if any. The private_key setting holds either
+// the key itself or the path to a file containing it, as a PEM block or as a
+// JWK, the format the Okta admin console shows generated keys in. The SDKs
+// only read PEM keys, so JWKs are converted.
+func readPrivateKey(privateKey string) (string, string, error) {
+	if privateKey != "" && !strings.Contains(privateKey, "-----BEGIN") && !isJSONWebKey(privateKey) {
+		content, err := os.ReadFile(privateKey)
+		if err != nil {
+			return "", "", fmt.Errorf("private_key is neither a PEM encoded key, a JWK nor a readable file: %v", err)
+		}
+		privateKey = string(content)
+	}
+
+	if isJSONWebKey(privateKey) {
+		return jsonWebKeyToPEM(privateKey)
+	}
+	// Keys set in environment variables often have escaped newlines
+	return strings.ReplaceAll(privateKey, `\n`, "\n"), "", nil
+}
+
+func isJSONWebKey(privateKey string) bool {
+	return strings.HasPrefix(strings.TrimSpace(privateKey), "{")
+}
+
+// jsonWebKeyToPEM converts an RSA private key in JWK format, or the first
+// private key of a JWK set, to a PKCS #1 PEM block
+func jsonWebKeyToPEM(privateKey string) (string, string, error) {
+	var key jose.JSONWebKey
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(privateKey), &keySet); err == nil && len(keySet.Keys) > 0 {
+		for _, k := range keySet.Keys {
+			if !k.IsPublic() {
+				key = k
+				break
+			}
+		}
+	} else if err := json.Unmarshal([]byte(privateKey), &key); err != nil {
+		return "", "", fmt.Errorf("invalid private key: %v", err)
+	}
+
+	rsaKey, ok := key.Key.(*rsa.PrivateKey)
+	if !ok {
+		return "", "", errors.New("invalid private key: the JWK is not an RSA private key")
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	return string(block), key.KeyID, nil
+}
+
+// parsePrivateKey parses a PKCS #1 or PKCS #8 PEM encoded RSA private key
+func parsePrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.New("invalid private key: no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("invalid private key: only RSA keys are supported")
+		}
+		return rsaKey, nil
+	}
+	return nil, fmt.Errorf("invalid private key: unsupported PEM block type %q", block.Type)
+}
+
+// newPrivateKeySigner returns the signer of the client assertions sent by the
+// v2 SDK client, which only reads PKCS #1 keys and can't set the key ID itself
+func newPrivateKeySigner(privateKey, privateKeyID string) (jose.Signer, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &jose.SignerOptions{}
+	if privateKeyID != "" {
+		options = options.WithHeader("kid", privateKeyID)
+	}
+	return jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, options)
+}
@@ -0,0 +1,104 @@
+package okta
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/context_key"
+)
+
+// queryMetrics counts the API calls a query made to an org, so the cost of a
+// query, e.g. of a dashboard panel, can be read from the plugin log
+type queryMetrics struct {
+	start         time.Time
+	calls         atomic.Int64
+	retries       atomic.Int64
+	rateLimitWait atomic.Int64
+}
+
+// Metrics of the running queries, keyed by query and connection
+var runningQueries sync.Map
+
+type queryMetricsKey struct {
+	query      string
+	connection string
+}
+
+type queryMetricsContextKey struct{}
+
+// trackQueryMetrics starts counting the API calls of the query, if not done
+// yet, and logs the counts once the query is over. The SDK gives each query
+// a logger named after its call ID and cancels its context when it ends.
+func trackQueryMetrics(ctx context.Context, d *plugin.QueryData) {
+	logger, ok := ctx.Value(context_key.Logger).(hclog.Logger)
+	if !ok || d.Connection == nil {
+		return
+	}
+	key := queryMetricsKey{query: logger.Name(), connection: d.Connection.Name}
+	if _, ok := runningQueries.Load(key); ok {
+		return
+	}
+	metrics := &queryMetrics{start: time.Now()}
+	if _, loaded := runningQueries.LoadOrStore(key, metrics); loaded {
+		return
+	}
+
+	table := ""
+	if d.Table != nil {
+		table = d.Table.Name
+	}
+	context.AfterFunc(ctx, func() {
+		runningQueries.Delete(key)
+		logger.Info("okta_query_metrics", "table", table, "connection", key.connection,
+			"api_calls", metrics.calls.Load(),
+			"retries", metrics.retries.Load(),
+			"rate_limit_wait", time.Duration(metrics.rateLimitWait.Load()),
+			"duration", time.Since(metrics.start))
+	})
+}
+
+// getQueryMetrics returns the metrics of the query a request is sent for, if
+// any, e.g. not for the token requests the SDKs send without the query context
+func getQueryMetrics(ctx context.Context) *queryMetrics {
+	metrics, _ := ctx.Value(queryMetricsContextKey{}).(*queryMetrics)
+	return metrics
+}
+
+func (m *queryMetrics) addRetry() {
+	if m != nil {
+		m.retries.Add(1)
+	}
+}
+
+func (m *queryMetrics) addRateLimitWait(wait time.Duration) {
+	if m != nil {
+		m.rateLimitWait.Add(int64(wait))
+	}
+}
+
+// metricsTransport counts the API calls of each query. The metrics are added
+// to the context of the request, so the transports it wraps count the
+// retries and the waits for rate limits.
+type metricsTransport struct {
+	base       http.RoundTripper
+	connection string
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger, ok := req.Context().Value(context_key.Logger).(hclog.Logger)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	value, ok := runningQueries.Load(queryMetricsKey{query: logger.Name(), connection: t.connection})
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	metrics := value.(*queryMetrics)
+	metrics.calls.Add(1)
+	return t.base.RoundTrip(req.WithContext(context.WithValue(req.Context(), queryMetricsContextKey{}, metrics)))
+}
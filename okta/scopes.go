@@ -0,0 +1,85 @@
+package okta
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// OAuth scopes needed by the list and get calls of each table when connecting
+// with a service app. Columns fetched with other APIs may need more scopes.
+// Tables that skip the sources they can't read, e.g. the identity providers
+// of okta_certificate, only list the scopes of the sources they always read.
+var tableScopes = map[string][]string{
+	"okta_access_request_condition":  {"okta.apps.read", "okta.governance.accessRequests.read"},
+	"okta_app_assigned_group":        {"okta.apps.read"},
+	"okta_app_assigned_user":         {"okta.apps.read"},
+	"okta_application":               {"okta.apps.read"},
+	"okta_auth_server":               {"okta.authorizationServers.read"},
+	"okta_authentication_policy":     {"okta.policies.read"},
+	"okta_authenticator":             {"okta.authenticators.read"},
+	"okta_certificate":               {"okta.apps.read"},
+	"okta_device":                    {"okta.devices.read"},
+	"okta_factor":                    {"okta.users.read"},
+	"okta_governance_grant":          {"okta.governance.entitlements.read"},
+	"okta_governance_resource_owner": {"okta.governance.resourceOwner.read"},
+	"okta_group":                     {"okta.groups.read"},
+	"okta_group_owner":               {"okta.groups.read"},
+	"okta_group_rule":                {"okta.groups.read"},
+	"okta_idp_discovery_policy":      {"okta.policies.read"},
+	"okta_mfa_policy":                {"okta.policies.read"},
+	"okta_network_zone":              {"okta.networkZones.read"},
+	"okta_password_policy":           {"okta.policies.read"},
+	"okta_profile_enrollment_policy": {"okta.policies.read"},
+	"okta_signon_policy":             {"okta.policies.read"},
+	"okta_trusted_origin":            {"okta.trustedOrigins.read"},
+	"okta_user":                      {"okta.users.read"},
+	"okta_user_group":                {"okta.users.read", "okta.groups.read"},
+	"okta_user_mfa_summary":          {"okta.users.read"},
+	"okta_user_type":                 {"okta.userTypes.read"},
+}
+
+// checkTableScopes fails the table up front when the connection uses a service
+// app and the scopes requested for its access token miss one the table needs,
+// rather than letting its API calls fail with a bare 403 Forbidden error
+func checkTableScopes(d *plugin.QueryData) error {
+	if d.Table == nil || len(tableScopes[d.Table.Name]) == 0 {
+		return nil
+	}
+	config := GetConfig(d.Connection)
+	if !isServiceAppConnection(config) {
+		return nil
+	}
+
+	scopes := getScopesValue(config)
+	if len(scopes) == 0 {
+		scopes = defaultScopesV4
+	}
+	var missing []string
+	for _, scope := range tableScopes[d.Table.Name] {
+		if !slices.Contains(scopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if len(missing) == 1 {
+		return fmt.Errorf("table %s needs the %s OAuth scope: grant %s to the service app and add it to the scopes of the connection", d.Table.Name, missing[0], missing[0])
+	}
+	return fmt.Errorf("table %s needs the %s OAuth scopes: grant %s to the service app and add them to the scopes of the connection", d.Table.Name, strings.Join(missing, ", "), strings.Join(missing, " and "))
+}
+
+// isServiceAppConnection returns whether the connection authenticates with the
+// client ID and private key of a service app rather than an API token
+func isServiceAppConnection(config oktaConfig) bool {
+	return getStringValue(config.Domain, "OKTA_CLIENT_ORGURL") != "" &&
+		getStringValue(config.Token, "OKTA_CLIENT_TOKEN") == "" &&
+		getStringValue(config.TokenCmd, "OKTA_CLIENT_TOKEN_CMD") == "" &&
+		getStringValue(config.AccessToken, "OKTA_ACCESS_TOKEN") == "" &&
+		getStringValue(config.ClientID, "OKTA_CLIENT_CLIENTID") != "" &&
+		getStringValue(config.PrivateKey, "OKTA_CLIENT_PRIVATEKEY") != ""
+}
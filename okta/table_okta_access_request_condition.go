@@ -0,0 +1,225 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v2/okta"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaAccessRequestCondition() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_access_request_condition",
+		Description: "Okta Identity Governance access request conditions define who can request access to a resource, the approval sequence used and how long access is granted for.",
+		List: &plugin.ListConfig{
+			ParentHydrate: getOrListOktaApplications,
+			Hydrate:       listOktaAccessRequestConditions,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "app_id", Require: plugin.Optional},
+			},
+		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{
+				Func:           getOktaAccessRequestSettings,
+				MaxConcurrency: 10,
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the request condition."},
+			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for the request condition."},
+			{Name: "app_id", Type: proto.ColumnType_STRING, Description: "Unique key for the application the request condition belongs to."},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "Status of the request condition: ACTIVE, INACTIVE or INVALID."},
+
+			// Other Columns
+			{Name: "description", Type: proto.ColumnType_STRING, Description: "Description of the request condition."},
+			{Name: "priority", Type: proto.ColumnType_INT, Description: "Priority of the request condition. The lowest priority condition that matches the requester is applied."},
+			{Name: "approval_sequence_id", Type: proto.ColumnType_STRING, Description: "Unique key for the approval sequence used for requests matching this condition."},
+			{Name: "requester_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("RequesterSettings.type"), Description: "Who can request access: EVERYONE or GROUPS."},
+			{Name: "access_duration", Type: proto.ColumnType_STRING, Transform: transform.FromField("AccessDurationSettings.duration"), Description: "The ISO 8601 duration access is granted for, if access is time bound."},
+			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the request condition was created."},
+			{Name: "created_by", Type: proto.ColumnType_STRING, Description: "The ID of the user who created the request condition."},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the request condition was last updated."},
+			{Name: "last_updated_by", Type: proto.ColumnType_STRING, Description: "The ID of the user who last updated the request condition."},
+
+			// JSON Columns
+			{Name: "requester_settings", Type: proto.ColumnType_JSON, Description: "The users or groups that can request access."},
+			{Name: "access_scope_settings", Type: proto.ColumnType_JSON, Description: "The groups or entitlement bundles that can be requested."},
+			{Name: "access_duration_settings", Type: proto.ColumnType_JSON, Description: "How long access is granted for."},
+			{Name: "request_settings", Type: proto.ColumnType_JSON, Hydrate: getOktaAccessRequestSettings, Transform: transform.FromValue(), Description: "The access request settings of the application, including whether access requests are enabled."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Name"), Description: titleDescription},
+		}),
+	}
+}
+
+type AccessRequestCondition struct {
+	AppId                  string
+	Id                     string                 `json:"id,omitempty"`
+	Name                   string                 `json:"name,omitempty"`
+	Description            string                 `json:"description,omitempty"`
+	Status                 string                 `json:"status,omitempty"`
+	Priority               *int64                 `json:"priority,omitempty"`
+	ApprovalSequenceId     string                 `json:"approvalSequenceId,omitempty"`
+	RequesterSettings      map[string]interface{} `json:"requesterSettings,omitempty"`
+	AccessScopeSettings    map[string]interface{} `json:"accessScopeSettings,omitempty"`
+	AccessDurationSettings map[string]interface{} `json:"accessDurationSettings,omitempty"`
+	Created                *time.Time             `json:"created,omitempty"`
+	CreatedBy              string                 `json:"createdBy,omitempty"`
+	LastUpdated            *time.Time             `json:"lastUpdated,omitempty"`
+	LastUpdatedBy          string                 `json:"lastUpdatedBy,omitempty"`
+}
+
+//// LIST FUNCTION
+
+func listOktaAccessRequestConditions(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	app, ok := h.Item.(*ApplicationStructure)
+	if !ok || app == nil {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("okta_access_request_condition.listOktaAccessRequestConditions", "connect_error", err)
+		return nil, err
+	}
+
+	// https://developer.okta.com/docs/api/iga/openapi/governance.requests.admin.v2/tag/Request-Conditions/
+	path := fmt.Sprintf("/governance/api/v2/resources/%s/request-conditions", app.Id)
+	err = listGovernanceResources(ctx, client, path, nil, func(data json.RawMessage) (bool, error) {
+		var conditions []AccessRequestCondition
+		if err := json.Unmarshal(data, &conditions); err != nil {
+			return false, err
+		}
+		for _, condition := range conditions {
+			condition.AppId = app.Id
+			d.StreamListItem(ctx, condition)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		// Applications that are not governed by Okta Identity Governance have no request conditions
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("okta_access_request_condition.listOktaAccessRequestConditions", "api_error", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+//// HYDRATE FUNCTIONS
+
+var getOktaAccessRequestSettingsMemoized = plugin.HydrateFunc(getOktaAccessRequestSettingsUncached).Memoize(memoize.WithCacheKeyFunction(getOktaAccessRequestSettingsCacheKey))
+
+// getOktaAccessRequestSettings returns the request settings of the application
+// of the condition. They are only fetched once for all the conditions of an
+// application.
+func getOktaAccessRequestSettings(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	settings, err := getOktaAccessRequestSettingsMemoized(ctx, d, &plugin.HydrateData{Item: h.Item.(AccessRequestCondition).AppId})
+	if err != nil {
+		plugin.Logger(ctx).Error("okta_access_request_condition.getOktaAccessRequestSettings", "api_error", err)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func getOktaAccessRequestSettingsCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("getOktaAccessRequestSettings-%s", h.Item.(string))
+	return key, nil
+}
+
+func getOktaAccessRequestSettingsUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	requestExecutor := client.GetRequestExecutor()
+	req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", fmt.Sprintf("/governance/api/v2/resources/%s/request-settings", h.Item.(string)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings map[string]interface{}
+	response, err := requestExecutor.Do(ctx, req, &settings)
+	if err = withStatusCode(err, response); err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+//// UTILITY FUNCTIONS
+
+// Okta Identity Governance list responses wrap the items in a data array and
+// return the cursor for the next page in the body rather than the Link header
+type governanceListResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Links struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next,omitempty"`
+	} `json:"_links"`
+}
+
+// listGovernanceResources pages through an Okta Identity Governance list
+// endpoint, passing the data array of each page to the callback until the
+// callback returns false or there are no more pages
+func listGovernanceResources(ctx context.Context, client *okta.Client, path string, params url.Values, page func(json.RawMessage) (bool, error)) error {
+	requestExecutor := client.GetRequestExecutor()
+
+	if len(params) > 0 {
+		path = path + "?" + params.Encode()
+	}
+
+	for path != "" {
+		req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", path, nil)
+		if err != nil {
+			return err
+		}
+
+		var resp governanceListResponse
+		response, err := requestExecutor.Do(ctx, req, &resp)
+		if err = withStatusCode(err, response); err != nil {
+			return err
+		}
+
+		more, err := page(resp.Data)
+		if err != nil || !more {
+			return err
+		}
+
+		path = ""
+		if resp.Links.Next != nil && resp.Links.Next.Href != "" {
+			next, err := url.Parse(resp.Links.Next.Href)
+			if err != nil {
+				return err
+			}
+			path = next.RequestURI()
+		}
+	}
+
+	return nil
+}
@@ -2,13 +2,14 @@ package okta
 
 import (
 	"context"
+	"fmt"
 	"slices"
-	"strings"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/okta/okta-sdk-golang/v2/okta/query"
 	"github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -21,30 +22,50 @@ func tableOktaApplicationAssignedGroup() *plugin.Table {
 		Name:        "okta_app_assigned_group",
 		Description: "Represents an application group assignment.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getApplicationAssignedGroup,
-			KeyColumns:        plugin.AllColumns([]string{"id", "app_id"}),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getApplicationAssignedGroup,
+			KeyColumns:   plugin.AllColumns([]string{"id", "app_id"}),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			ParentHydrate: getOrListOktaApplications,
 			Hydrate:       listApplicationAssignedGroups,
 			KeyColumns: plugin.KeyColumnSlice{
 				{Name: "app_id", Require: plugin.Optional},
+				{Name: "group_id", Require: plugin.Optional},
+			},
+		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{
+				Func:           getAppAssignedGroupName,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           getAppAssignedGroupStats,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           listAppAssignedGroupSampleMembers,
+				MaxConcurrency: 10,
 			},
 		},
-
 		Columns: commonColumns([]*plugin.Column{
 			// Top Columns
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for the group."},
 			{Name: "app_id", Type: proto.ColumnType_STRING, Description: "Unique key for the application."},
+			{Name: "group_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Id"), Description: "Unique key for the group. Specify it to only list the applications assigned to the group."},
+			{Name: "group_name", Type: proto.ColumnType_STRING, Hydrate: getAppAssignedGroupName, Transform: transform.FromValue(), Description: "Name of the group."},
+			{Name: "app_label", Type: proto.ColumnType_STRING, Description: "User-defined display name for the application."},
+			{Name: "app_name", Type: proto.ColumnType_STRING, Description: "Unique key for the application definition."},
 
 			// Other Columns
 			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when group was last updated."},
-			{Name: "priority", Type: proto.ColumnType_INT, Description: "Priority of the group"},
+			{Name: "priority", Type: proto.ColumnType_INT, Description: "Priority of the group assignment. The profile of the highest priority group, with the lowest value, is applied to users assigned through several groups."},
+			{Name: "group_members_count", Type: proto.ColumnType_INT, Hydrate: getAppAssignedGroupStats, Transform: transform.FromField("Embedded.stats.usersCount"), Description: "The number of users that are a member of the group, and so assigned to the application through it."},
 
 			// JSON Columns
 			{Name: "links", Type: proto.ColumnType_JSON, Description: "The link details of the group."},
-			{Name: "profile", Type: proto.ColumnType_JSON, Description: "The profile details of the group."},
+			{Name: "profile", Type: proto.ColumnType_JSON, Description: "The app user profile attributes applied to the users assigned through the group."},
+			{Name: "group_sample_members", Type: proto.ColumnType_JSON, Hydrate: listAppAssignedGroupSampleMembers, Transform: transform.FromValue(), Description: "The first 10 members of the group, with their ID, email and login."},
 
 			// Steampipe Columns
 			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Id"), Description: titleDescription},
@@ -53,16 +74,23 @@ func tableOktaApplicationAssignedGroup() *plugin.Table {
 }
 
 type AppGroupInfo struct {
-	AppId string
+	AppId    string
+	AppLabel string
+	AppName  string
 	okta.ApplicationGroupAssignment
 }
 
+func (i AppGroupInfo) RawObject() interface{} {
+	return i.ApplicationGroupAssignment
+}
+
 //// LIST FUNCTION
 
 func listApplicationAssignedGroups(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listApplicationAssignedGroups")
-	appId := h.Item.(*okta.Application).Id
+	app := h.Item.(*ApplicationStructure)
+	appId := app.Id
 
 	client, err := Connect(ctx, d)
 	if err != nil {
@@ -84,19 +112,25 @@ func listApplicationAssignedGroups(ctx context.Context, d *plugin.QueryData, h *
 		}
 	}
 
+	// The parent only lists the apps assigned to the group, so get the
+	// assignment rather than listing every group of the app
+	if groupId := d.EqualsQualString("group_id"); groupId != "" {
+		group, _, err := client.Application.GetApplicationGroupAssignment(ctx, appId, groupId, &query.Params{})
+		if err != nil {
+			if isNotFoundError(err) {
+				return nil, nil
+			}
+			logger.Error("listApplicationAssignedGroups", "get_app_group_error", err)
+			return nil, err
+		}
+		d.StreamListItem(ctx, AppGroupInfo{AppId: appId, AppLabel: app.Label, AppName: app.Name, ApplicationGroupAssignment: *group})
+		return nil, nil
+	}
+
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/apps/#list-groups-assigned-to-application
 	input := query.Params{
-		Limit: 200,
-	}
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
+		Limit: getListPageSize(d, 200),
 	}
 
 	groups, resp, err := client.Application.ListApplicationGroupAssignments(ctx, appId, &input)
@@ -106,31 +140,12 @@ func listApplicationAssignedGroups(ctx context.Context, d *plugin.QueryData, h *
 		return nil, err
 	}
 
-	for _, group := range groups {
-		d.StreamListItem(ctx, AppGroupInfo{appId, *group})
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextGroupSet []*okta.ApplicationGroupAssignment
-		resp, err = resp.Next(ctx, &nextGroupSet)
-		if err != nil {
-			logger.Error("listApplicationAssignedGroups", "list_app_groups_paging_error", err)
-			return nil, err
-		}
-		for _, group := range nextGroupSet {
-			d.StreamListItem(ctx, AppGroupInfo{appId, *group})
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
+	err = streamPages(ctx, d, groups, v2Pages[*okta.ApplicationGroupAssignment](ctx, resp), func(group *okta.ApplicationGroupAssignment) interface{} {
+		return AppGroupInfo{AppId: appId, AppLabel: app.Label, AppName: app.Name, ApplicationGroupAssignment: *group}
+	})
+	if err != nil {
+		logger.Error("listApplicationAssignedGroups", "list_app_groups_paging_error", err)
+		return nil, err
 	}
 
 	return nil, nil
@@ -160,7 +175,94 @@ func getApplicationAssignedGroup(ctx context.Context, d *plugin.QueryData, h *pl
 		return nil, err
 	}
 
-	return AppGroupInfo{appId, *group}, nil
+	app, err := getApplicationWithSettings(ctx, *client, appId)
+	if err != nil {
+		logger.Error("getApplicationAssignedGroup", "get_application_error", err)
+		return nil, err
+	}
+
+	return AppGroupInfo{AppId: appId, AppLabel: app.Label, AppName: app.Name, ApplicationGroupAssignment: *group}, nil
+}
+
+func getAppAssignedGroupName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	groupId := h.Item.(AppGroupInfo).Id
+
+	name, err := getOktaGroupName(ctx, d, &plugin.HydrateData{Item: groupId})
+	if err != nil {
+		plugin.Logger(ctx).Error("getAppAssignedGroupName", "get_group_error", err)
+		return nil, err
+	}
+
+	return name, nil
+}
+
+var getOktaGroupStatsMemoized = plugin.HydrateFunc(getOktaGroupStatsUncached).Memoize(memoize.WithCacheKeyFunction(getOktaGroupStatsCacheKey))
+
+// getAppAssignedGroupStats returns the group with its stats embedded. The
+// stats of a group assigned to several applications are only fetched once.
+func getAppAssignedGroupStats(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	group, err := getOktaGroupStatsMemoized(ctx, d, &plugin.HydrateData{Item: h.Item.(AppGroupInfo).Id})
+	if err != nil {
+		plugin.Logger(ctx).Error("getAppAssignedGroupStats", "get_group_error", err)
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func getOktaGroupStatsCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("getOktaGroupStats-%s", h.Item.(string))
+	return key, nil
+}
+
+func getOktaGroupStatsUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// The get group API doesn't support expand, so list the group by ID
+	input := query.Params{
+		Filter: fmt.Sprintf("id eq \"%s\"", h.Item.(string)),
+		Expand: "stats",
+	}
+	groups, _, err := listGroupsWithProfile(ctx, *client, &input)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	return groups[0], nil
+}
+
+// Number of members returned in the group_sample_members column
+const groupSampleMembersSize = 10
+
+// listAppAssignedGroupSampleMembers returns the first members of the group,
+// from a single page of members, to review who an assignment applies to
+// without listing every member of large groups
+func listAppAssignedGroupSampleMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	groupId := h.Item.(AppGroupInfo).Id
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("listAppAssignedGroupSampleMembers", "connect_error", err)
+		return nil, err
+	}
+
+	users, resp, err := client.Group.ListGroupUsers(ctx, groupId, &query.Params{Limit: groupSampleMembersSize})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("listAppAssignedGroupSampleMembers", "list_group_users_error", err)
+		return nil, withStatusCode(err, resp)
+	}
+
+	return appendGroupMembers(make([]map[string]string, 0, len(users)), users), nil
 }
 
 //// PARENT HYDRATE FUNCTION
@@ -176,7 +278,7 @@ func getOrListOktaApplications(ctx context.Context, d *plugin.QueryData, h *plug
 		// The okta_application table uses the "id" column instead
 		d.EqualsQuals["id"] = d.EqualsQuals["app_id"]
 		app, err := getOktaApplication(ctx, d, h)
-		if err != nil && !strings.Contains(err.Error(), "Not found") {
+		if err != nil && !isNotFoundError(err) {
 			logger.Error("getOrListOktaApplications", "get_application_error", err)
 			return nil, err
 		}
@@ -2,6 +2,7 @@ package okta
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/okta/okta-sdk-golang/v2/okta/query"
@@ -13,14 +14,18 @@ import (
 
 //// TABLE DEFINITION
 
-func tableOktaApplicationAssignedUser() *plugin.Table {
+func tableOktaApplicationAssignedUser(ctx context.Context, td *plugin.TableMapData) *plugin.Table {
 	return &plugin.Table{
 		Name:        "okta_app_assigned_user",
 		Description: "Represents all assigned users for applications.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getApplicationAssignedUser,
-			KeyColumns:        plugin.AllColumns([]string{"id", "app_id"}),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate: getApplicationAssignedUser,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "app_id", Require: plugin.Required},
+				{Name: "id", Require: plugin.AnyOf},
+				{Name: "user_name", Require: plugin.AnyOf},
+			},
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			ParentHydrate: getOrListOktaApplications,
@@ -30,14 +35,18 @@ func tableOktaApplicationAssignedUser() *plugin.Table {
 				{Name: "user_name", Require: plugin.Optional},
 				{Name: "first_name", Require: plugin.Optional},
 				{Name: "email", Require: plugin.Optional},
+				{Name: "scope", Require: plugin.Optional},
+				{Name: "sync_state", Require: plugin.Optional},
 			},
 		},
 
-		Columns: commonColumns([]*plugin.Column{
+		Columns: commonColumns(append([]*plugin.Column{
 			// Top Columns
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for the application user."},
 			{Name: "user_name", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.UserName"), Description: "The username of the application user."},
 			{Name: "app_id", Type: proto.ColumnType_STRING, Description: "Unique key for the application."},
+			{Name: "app_label", Type: proto.ColumnType_STRING, Description: "User-defined display name for the application."},
+			{Name: "app_name", Type: proto.ColumnType_STRING, Description: "Unique key for the application definition."},
 			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when application user was last updated."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "The status of the application user."},
 
@@ -49,9 +58,9 @@ func tableOktaApplicationAssignedUser() *plugin.Table {
 			{Name: "last_sync", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when application user was last synced."},
 			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when application user was last updated."},
 			{Name: "password_changed", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when application user's password was last changed."},
-			{Name: "scope", Type: proto.ColumnType_STRING, Description: "The scope of the application user."},
+			{Name: "scope", Type: proto.ColumnType_STRING, Description: "Indicates if the user is assigned to the application directly (USER) or through a group (GROUP)."},
 			{Name: "status_changed", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when application user's status was last changed."},
-			{Name: "sync_state", Type: proto.ColumnType_STRING, Description: "The sync state of the application user."},
+			{Name: "sync_state", Type: proto.ColumnType_STRING, Description: "The synchronization state of the application user. Can be one of DISABLED, OUT_OF_SYNC, SYNCING, SYNCHRONIZED or ERROR."},
 
 			// JSON Columns
 			{Name: "links", Type: proto.ColumnType_JSON, Description: "The link details of the application user."},
@@ -59,21 +68,28 @@ func tableOktaApplicationAssignedUser() *plugin.Table {
 
 			// Steampipe Columns
 			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Id"), Description: titleDescription},
-		}),
+		}, appUserProfileColumns(ctx, td)...)),
 	}
 }
 
 type AppUserInfo struct {
-	AppId string
+	AppId    string
+	AppLabel string
+	AppName  string
 	okta.AppUser
 }
 
+func (i AppUserInfo) RawObject() interface{} {
+	return i.AppUser
+}
+
 //// LIST FUNCTION
 
 func listApplicationAssignedUsers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	logger.Trace("listApplicationAssignedUsers")
-	appId := h.Item.(*okta.Application).Id
+	app := h.Item.(*ApplicationStructure)
+	appId := app.Id
 
 	client, err := Connect(ctx, d)
 	if err != nil {
@@ -84,7 +100,7 @@ func listApplicationAssignedUsers(ctx context.Context, d *plugin.QueryData, h *p
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/apps/#list-users-assigned-to-application
 	input := &query.Params{
-		Limit: 500,
+		Limit: getPageSize(d, 500),
 	}
 
 	if d.EqualsQualString("user_name") != "" {
@@ -95,13 +111,15 @@ func listApplicationAssignedUsers(ctx context.Context, d *plugin.QueryData, h *p
 		input.Q = d.EqualsQualString("email")
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
+	// The API can't filter on scope or sync state, so skip the other users here
+	// rather than streaming every assignment of large apps
+	scope := d.EqualsQualString("scope")
+	syncState := d.EqualsQualString("sync_state")
+
+	// Pages are only bounded by the limit of the query when no users are
+	// skipped below
+	if scope == "" && syncState == "" {
+		input.Limit = getListPageSize(d, 500)
 	}
 
 	users, resp, err := client.Application.ListApplicationUsers(ctx, appId, input)
@@ -111,31 +129,15 @@ func listApplicationAssignedUsers(ctx context.Context, d *plugin.QueryData, h *p
 		return nil, err
 	}
 
-	for _, user := range users {
-		d.StreamListItem(ctx, AppUserInfo{appId, *user})
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextUserSet []*okta.AppUser
-		resp, err = resp.Next(ctx, &nextUserSet)
-		if err != nil {
-			logger.Error("listApplicationAssignedUsers", "list_app_users_paging_error", err)
-			return nil, err
-		}
-		for _, user := range nextUserSet {
-			d.StreamListItem(ctx, AppUserInfo{appId, *user})
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+	err = streamPages(ctx, d, users, v2Pages[*okta.AppUser](ctx, resp), func(user *okta.AppUser) interface{} {
+		if (scope != "" && user.Scope != scope) || (syncState != "" && user.SyncState != syncState) {
+			return nil
 		}
+		return AppUserInfo{AppId: appId, AppLabel: app.Label, AppName: app.Name, AppUser: *user}
+	})
+	if err != nil {
+		logger.Error("listApplicationAssignedUsers", "list_app_users_paging_error", err)
+		return nil, err
 	}
 
 	return nil, nil
@@ -148,8 +150,9 @@ func getApplicationAssignedUser(ctx context.Context, d *plugin.QueryData, h *plu
 	logger.Trace("getApplicationAssignedUser")
 	appId := d.EqualsQuals["app_id"].GetStringValue()
 	userId := d.EqualsQuals["id"].GetStringValue()
+	userName := d.EqualsQualString("user_name")
 
-	if appId == "" || userId == "" {
+	if appId == "" || (userId == "" && userName == "") {
 		return nil, nil
 	}
 
@@ -159,11 +162,92 @@ func getApplicationAssignedUser(ctx context.Context, d *plugin.QueryData, h *plu
 		return nil, err
 	}
 
-	user, _, err := client.Application.GetApplicationUser(ctx, appId, userId, &query.Params{})
+	var user *okta.AppUser
+	if userId != "" {
+		user, _, err = client.Application.GetApplicationUser(ctx, appId, userId, &query.Params{})
+		if err != nil {
+			logger.Error("getApplicationAssignedUser", "get_app_user_error", err)
+			return nil, err
+		}
+	} else {
+		user, err = getApplicationUserByName(ctx, d, client, appId, userName)
+		if err != nil {
+			logger.Error("getApplicationAssignedUser", "get_app_user_by_name_error", err)
+			return nil, err
+		}
+		if user == nil {
+			return nil, nil
+		}
+	}
+
+	app, err := getApplicationWithSettings(ctx, *client, appId)
+	if err != nil {
+		logger.Error("getApplicationAssignedUser", "get_application_error", err)
+		return nil, err
+	}
+
+	return AppUserInfo{AppId: appId, AppLabel: app.Label, AppName: app.Name, AppUser: *user}, nil
+}
+
+// getApplicationUserByName returns the user assigned to the app whose app
+// username is userName, if any. The q parameter matches the start of the
+// usernames, names and emails, so the users it returns are matched exactly,
+// paging through them until the match is found.
+func getApplicationUserByName(ctx context.Context, d *plugin.QueryData, client *okta.Client, appId string, userName string) (*okta.AppUser, error) {
+	users, resp, err := client.Application.ListApplicationUsers(ctx, appId, &query.Params{Q: userName, Limit: getPageSize(d, 500)})
+	if err != nil {
+		return nil, withStatusCode(err, resp)
+	}
+
+	var match *okta.AppUser
+	err = forEachPage(ctx, users, v2Pages[*okta.AppUser](ctx, resp), func(page []*okta.AppUser) bool {
+		for _, user := range page {
+			if user.Credentials != nil && user.Credentials.UserName == userName {
+				match = user
+				return false
+			}
+		}
+		return true
+	})
 	if err != nil {
-		logger.Error("getApplicationAssignedUser", "get_app_user_error", err)
 		return nil, err
 	}
+	return match, nil
+}
+
+//// TRANSFORM FUNCTION
+
+func appUserProfileAttribute(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	profile, ok := d.HydrateItem.(AppUserInfo).Profile.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return profile[d.Param.(string)], nil
+}
 
-	return AppUserInfo{appId, *user}, nil
+//// UTILITY FUNCTION
+
+// App user profile attributes that are already exposed as top level columns
+var appUserProfileColumnExclusions = []string{"email", "family_name", "given_name"}
+
+// appUserProfileColumns returns a column for each attribute of the app user
+// schemas of the apps listed in the app_user_schema_app_ids connection config.
+// The columns of a table are fixed per connection, so they can't be generated
+// for the app_id of each query. Attributes of other apps are null.
+func appUserProfileColumns(ctx context.Context, td *plugin.TableMapData) []*plugin.Column {
+	columns := []*plugin.Column{}
+	seen := map[string]bool{}
+	for _, appId := range GetConfig(td.Connection).AppUserSchemaAppIds {
+		// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Schema/#tag/Schema/operation/getApplicationUserSchema
+		schemaPath := fmt.Sprintf("/api/v1/meta/schemas/apps/%s/default", appId)
+		for _, column := range profileSchemaColumns(ctx, td, schemaPath, true, appUserProfileColumnExclusions, appUserProfileAttribute) {
+			// The first app that defines an attribute determines its type
+			if seen[column.Name] {
+				continue
+			}
+			seen[column.Name] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns
 }
@@ -2,11 +2,16 @@ package okta
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/okta/okta-sdk-golang/v2/okta/query"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -19,20 +24,39 @@ func tableOktaApplication() *plugin.Table {
 		Name:        "okta_application",
 		Description: "An Application holds information about the protocol in which it wants Okta to communicate, policies for accessing the application, and which users can use the application after identifying themselves.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaApplication,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaApplication,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaApplications,
 			KeyColumns: plugin.KeyColumnSlice{
 				// https://developer.okta.com/docs/reference/api/apps/#filters
 				{Name: "name", Require: plugin.Optional},
+				{Name: "label", Require: plugin.Optional},
 				{Name: "status", Require: plugin.Optional},
+				{Name: "sign_on_mode", Require: plugin.Optional},
 				{Name: "filter", Require: plugin.Optional},
 			},
 		},
-
+		HydrateConfig: []plugin.HydrateConfig{
+			{
+				Func:           getApplicationSigningKey,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           getApplicationProvisioningFeatures,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           getApplicationAccessPolicyName,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           getApplicationSamlMetadata,
+				MaxConcurrency: 10,
+			},
+		},
 		Columns: commonColumns([]*plugin.Column{
 			// Top Columns
 			{Name: "name", Type: proto.ColumnType_STRING, Description: "Unique key for app definition."},
@@ -45,12 +69,37 @@ func tableOktaApplication() *plugin.Table {
 			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when app was last updated."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "Current status of app. Valid values are ACTIVE or INACTIVE."},
 			{Name: "sign_on_mode", Type: proto.ColumnType_STRING, Description: "Authentication mode of app. Can be one of AUTO_LOGIN, BASIC_AUTH, BOOKMARK, BROWSER_PLUGIN, Custom, OPENID_CONNECT, SAML_1_1, SAML_2_0, SECURE_PASSWORD_STORE and WS_FEDERATION."},
+			{Name: "access_policy_id", Type: proto.ColumnType_STRING, Transform: transform.From(applicationAccessPolicyId), Description: "Unique key for the authentication policy assigned to the app."},
+			{Name: "access_policy_name", Type: proto.ColumnType_STRING, Hydrate: getApplicationAccessPolicyName, Transform: transform.FromValue(), Description: "Name of the authentication policy assigned to the app."},
+			{Name: "access_policy_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "accessPolicy"), Description: "The API URL of the authentication policy assigned to the app."},
+			{Name: "logo_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "logo"), Description: "The URL of the logo of the app."},
+			{Name: "users_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "users"), Description: "The API URL listing the users assigned to the app."},
+			{Name: "groups_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "groups"), Description: "The API URL listing the groups assigned to the app."},
+			{Name: "sso_acs_url", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.signOn.ssoAcsUrl"), Description: "The assertion consumer service URL of a custom SAML app, where Okta sends the SAML response."},
+			{Name: "audience", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.signOn.audience"), Description: "The intended audience of the SAML assertion of a custom SAML app, usually the entity ID of the service provider."},
+			{Name: "recipient", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.signOn.recipient"), Description: "The location where a custom SAML app may present the SAML assertion."},
+			{Name: "destination", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.signOn.destination"), Description: "The location to send the SAML response of a custom SAML app."},
+			{Name: "signature_algorithm", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.signOn.signatureAlgorithm"), Description: "The signature algorithm used to sign the SAML response and assertion of a custom SAML app: RSA_SHA256 or RSA_SHA1."},
+			{Name: "default_relay_state", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.signOn.defaultRelayState"), Description: "The relay state sent with IdP-initiated sign-ins to a custom SAML app."},
+			{Name: "client_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.oauthClient.client_id"), Description: "The OAuth client ID of an OpenID Connect app."},
+			{Name: "token_endpoint_auth_method", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.oauthClient.token_endpoint_auth_method"), Description: "The method an OpenID Connect app uses to authenticate at the token endpoint, e.g. client_secret_basic, private_key_jwt or none."},
+			{Name: "pkce_required", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Credentials.oauthClient.pkce_required"), Description: "True if an OpenID Connect app requires PKCE."},
+			{Name: "oauth_application_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.oauthClient.application_type"), Description: "The type of an OpenID Connect app: web, native, browser or service."},
+			{Name: "saml_metadata", Type: proto.ColumnType_STRING, Hydrate: getApplicationSamlMetadata, Transform: transform.FromValue(), Description: "The SAML metadata XML of a SAML 2.0 app, describing Okta as the identity provider. Only fetched when selected."},
+			{Name: "signing_key_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.signing.kid"), Description: "The key ID (kid) of the active signing certificate of the app."},
+			{Name: "signing_key_rotation_mode", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.signing.rotationMode"), Description: "How the signing key of the app is rotated: AUTO or MANUAL."},
+			{Name: "signing_key_expires_at", Type: proto.ColumnType_TIMESTAMP, Hydrate: getApplicationSigningKey, Transform: transform.FromField("ExpiresAt"), Description: "Timestamp when the active signing certificate of the app expires."},
 
 			// JSON Columns
 			{Name: "settings", Type: proto.ColumnType_JSON, Description: "Settings for app."},
 			{Name: "visibility", Type: proto.ColumnType_JSON, Description: "Visibility settings for app."},
 			{Name: "credentials", Type: proto.ColumnType_JSON, Description: "Credentials for the specified signOnMode."},
 			{Name: "accessibility", Type: proto.ColumnType_JSON, Description: "Access settings for app."},
+			{Name: "provisioning_features", Type: proto.ColumnType_JSON, Hydrate: getApplicationProvisioningFeatures, Transform: transform.FromValue(), Description: "The status of the provisioning capabilities of the app: pushing new users, profile updates, deactivations and passwords, and importing users."},
+			{Name: "grant_types", Type: proto.ColumnType_JSON, Transform: transform.FromField("Settings.oauthClient.grant_types"), Description: "The OAuth grant types an OpenID Connect app can use."},
+			{Name: "response_types", Type: proto.ColumnType_JSON, Transform: transform.FromField("Settings.oauthClient.response_types"), Description: "The OAuth response types an OpenID Connect app can use."},
+			{Name: "redirect_uris", Type: proto.ColumnType_JSON, Transform: transform.FromField("Settings.oauthClient.redirect_uris"), Description: "The redirect URIs allowed for an OpenID Connect app."},
+			{Name: "post_logout_redirect_uris", Type: proto.ColumnType_JSON, Transform: transform.FromField("Settings.oauthClient.post_logout_redirect_uris"), Description: "The redirect URIs allowed after sign-out of an OpenID Connect app."},
 
 			// Steampipe Columns
 			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Name"), Description: titleDescription},
@@ -58,33 +107,58 @@ func tableOktaApplication() *plugin.Table {
 	}
 }
 
+// ApplicationStructure is an application with its full settings and
+// credentials. The SDK application drops the sign on settings of SAML apps and
+// the OAuth client settings and credentials of OpenID Connect apps.
+type ApplicationStructure struct {
+	Embedded      interface{}                    `json:"_embedded,omitempty"`
+	Links         interface{}                    `json:"_links,omitempty"`
+	Accessibility *okta.ApplicationAccessibility `json:"accessibility,omitempty"`
+	Created       *time.Time                     `json:"created,omitempty"`
+	Credentials   map[string]interface{}         `json:"credentials,omitempty"`
+	Features      []string                       `json:"features,omitempty"`
+	Id            string                         `json:"id,omitempty"`
+	Label         string                         `json:"label,omitempty"`
+	LastUpdated   *time.Time                     `json:"lastUpdated,omitempty"`
+	Licensing     *okta.ApplicationLicensing     `json:"licensing,omitempty"`
+	Name          string                         `json:"name,omitempty"`
+	Profile       interface{}                    `json:"profile,omitempty"`
+	Settings      map[string]interface{}         `json:"settings,omitempty"`
+	SignOnMode    string                         `json:"signOnMode,omitempty"`
+	Status        string                         `json:"status,omitempty"`
+	Visibility    *okta.ApplicationVisibility    `json:"visibility,omitempty"`
+}
+
 //// LIST FUNCTION
 
 func listOktaApplications(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	client, err := Connect(ctx, d)
-	if err != nil {
-		logger.Error("listOktaApplications", "connect_error", err)
-		return nil, err
-	}
 
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/apps/#list-applications
 	input := query.Params{
-		Limit: 200,
+		Limit: getPageSize(d, 200),
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
+	// The q parameter matches apps whose name or label starts with the value,
+	// so the exact label is checked again below
+	label := d.EqualsQualString("label")
+
+	// Pages are only bounded by the limit of the query when no applications
+	// are skipped below, and not when listing the parents of the rows of
+	// child tables such as okta_app_assigned_group, as the limit is theirs
+	if label == "" && d.Table.Name == "okta_application" {
+		input.Limit = getListPageSize(d, 200)
 	}
 
 	equalQuals := d.EqualsQuals
-	filter := buildQueryFilter(equalQuals, []string{"name", "status"})
+	filter := buildQueryFilter(equalQuals, []string{"name", "status", "sign_on_mode"})
+
+	// Child tables such as okta_app_assigned_group only need the apps assigned
+	// to a group when one is given
+	if groupId := d.EqualsQualString("group_id"); groupId != "" {
+		filter = append(filter, fmt.Sprintf("group.id eq \"%s\"", groupId))
+	}
 	var queryFilter string
 
 	if equalQuals["filter"] != nil {
@@ -97,43 +171,33 @@ func listOktaApplications(ctx context.Context, d *plugin.QueryData, _ *plugin.Hy
 		input.Filter = strings.Join(filter, " and ")
 	}
 
-	applications, resp, err := client.Application.ListApplications(ctx, &input)
+	if label != "" {
+		input.Q = label
+	}
+
+	applications, resp, err := listOktaApplicationsPage(ctx, d, "/api/v1/apps"+input.String())
 	if err != nil {
 		logger.Error("listOktaApplications", "list_applications_error", err)
-		if strings.Contains(err.Error(), "Not found") {
+		if isNotFoundError(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	for _, app := range applications {
-		d.StreamListItem(ctx, app)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextApplicationSet []*okta.Application
-		resp, err = resp.Next(ctx, &nextApplicationSet)
-		if err != nil {
-			logger.Error("listOktaApplications", "list_applications_paging_error", err)
-			return nil, err
-		}
-		for _, app := range nextApplicationSet {
-			d.StreamListItem(ctx, app)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+	err = streamPages(ctx, d, applications, urlPages(resp, func(url string) ([]*ApplicationStructure, *okta.Response, error) {
+		return listOktaApplicationsPage(ctx, d, url)
+	}), func(app *ApplicationStructure) interface{} {
+		if label != "" && app.Label != label {
+			return nil
 		}
+		return app
+	})
+	if err != nil {
+		logger.Error("listOktaApplications", "list_applications_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTION
@@ -148,13 +212,19 @@ func getOktaApplication(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 		return nil, nil
 	}
 
+	release, err := acquireConnectionSemaphore(ctx, d, "get_application", getConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	client, err := Connect(ctx, d)
 	if err != nil {
 		logger.Error("getOktaApplication", "connect_error", err)
 		return nil, err
 	}
 
-	app, _, err := client.Application.GetApplication(ctx, appId, okta.NewApplication(), &query.Params{})
+	app, err := getApplicationWithSettings(ctx, *client, appId)
 	if err != nil {
 		logger.Error("getOktaApplication", "get_application_error", err)
 		return nil, err
@@ -162,3 +232,278 @@ func getOktaApplication(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 
 	return app, nil
 }
+
+// Get the active signing key of the app, which signs the SAML and WS-Federation
+// assertions
+func getApplicationSigningKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	app := h.Item.(*ApplicationStructure)
+
+	kid := getApplicationSigningKeyId(app)
+	if kid == "" {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("getApplicationSigningKey", "connect_error", err)
+		return nil, err
+	}
+
+	keys, _, err := client.Application.ListApplicationKeys(ctx, app.Id)
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("getApplicationSigningKey", "list_application_keys_error", err)
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.Kid == kid {
+			return key, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ApplicationProvisioningFeatures summarizes the provisioning capabilities of
+// an app. Each capability is ENABLED or DISABLED.
+type ApplicationProvisioningFeatures struct {
+	UserProvisioning    string `json:"user_provisioning,omitempty"`
+	PushNewUsers        string `json:"push_new_users,omitempty"`
+	PushProfileUpdates  string `json:"push_profile_updates,omitempty"`
+	DeactivateUsers     string `json:"deactivate_users,omitempty"`
+	PushPasswordUpdates string `json:"push_password_updates,omitempty"`
+	ImportUsers         string `json:"import_users,omitempty"`
+}
+
+func getApplicationProvisioningFeatures(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	app := h.Item.(*ApplicationStructure)
+
+	// Apps without provisioning enabled have no features
+	if len(app.Features) == 0 {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("getApplicationProvisioningFeatures", "connect_error", err)
+		return nil, err
+	}
+
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/ApplicationFeatures/#tag/ApplicationFeatures/operation/listFeaturesForApplication
+	requestExecutor := client.GetRequestExecutor()
+	req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", fmt.Sprintf("/api/v1/apps/%s/features", app.Id), nil)
+	if err != nil {
+		logger.Error("getApplicationProvisioningFeatures", "request_error", err)
+		return nil, err
+	}
+
+	var features []map[string]interface{}
+	resp, err := requestExecutor.Do(ctx, req, &features)
+	if err = withStatusCode(err, resp); err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("getApplicationProvisioningFeatures", "api_error", err)
+		return nil, err
+	}
+
+	status := func(m map[string]interface{}) string {
+		if m == nil {
+			return ""
+		}
+		s, _ := m["status"].(string)
+		return s
+	}
+
+	summary := ApplicationProvisioningFeatures{}
+	for _, feature := range features {
+		switch feature["name"] {
+		case "USER_PROVISIONING":
+			summary.UserProvisioning = status(feature)
+			summary.PushNewUsers = status(getNestedMap(feature, "capabilities", "create", "lifecycleCreate"))
+			summary.PushProfileUpdates = status(getNestedMap(feature, "capabilities", "update", "profile"))
+			summary.DeactivateUsers = status(getNestedMap(feature, "capabilities", "update", "lifecycleDeactivate"))
+			summary.PushPasswordUpdates = status(getNestedMap(feature, "capabilities", "update", "password"))
+		case "INBOUND_PROVISIONING":
+			summary.ImportUsers = status(feature)
+		}
+	}
+
+	return summary, nil
+}
+
+func getApplicationSamlMetadata(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	app := h.Item.(*ApplicationStructure)
+	if app.SignOnMode != "SAML_2_0" {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("getApplicationSamlMetadata", "connect_error", err)
+		return nil, err
+	}
+
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/ApplicationSSO/#tag/ApplicationSSO/operation/previewSAMLmetadataForApplication
+	requestExecutor := client.GetRequestExecutor()
+	req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", fmt.Sprintf("/api/v1/apps/%s/sso/saml/metadata", app.Id), nil)
+	if err != nil {
+		logger.Error("getApplicationSamlMetadata", "request_error", err)
+		return nil, err
+	}
+	// Set the header on the request rather than the shared request executor
+	req.Header.Set("Accept", "application/xml")
+
+	// The response body is left intact, so read the XML document from it
+	// rather than decoding it
+	resp, err := requestExecutor.Do(ctx, req, nil)
+	if err = withStatusCode(err, resp); err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("getApplicationSamlMetadata", "api_error", err)
+		return nil, err
+	}
+
+	metadata, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("getApplicationSamlMetadata", "read_error", err)
+		return nil, err
+	}
+
+	return string(metadata), nil
+}
+
+func getApplicationAccessPolicyName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	policyId := getApplicationAccessPolicyId(h.Item.(*ApplicationStructure))
+	if policyId == "" {
+		return nil, nil
+	}
+
+	name, err := getOktaPolicyName(ctx, d, &plugin.HydrateData{Item: policyId})
+	if err != nil {
+		plugin.Logger(ctx).Error("getApplicationAccessPolicyName", "get_policy_error", err)
+		return nil, err
+	}
+
+	return name, nil
+}
+
+// Most apps share a handful of authentication policies, so the lookup is cached
+var getOktaPolicyNameMemoized = plugin.HydrateFunc(getOktaPolicyNameUncached).Memoize(memoize.WithCacheKeyFunction(getOktaPolicyNameCacheKey))
+
+func getOktaPolicyName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaPolicyNameMemoized(ctx, d, h)
+}
+
+func getOktaPolicyNameCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("getOktaPolicyName-%s", h.Item.(string))
+	return key, nil
+}
+
+func getOktaPolicyNameUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, _, err := client.Policy.GetPolicy(ctx, h.Item.(string), &query.Params{})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return policy.Name, nil
+}
+
+//// TRANSFORM FUNCTION
+
+func applicationAccessPolicyId(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	policyId := getApplicationAccessPolicyId(d.HydrateItem.(*ApplicationStructure))
+	if policyId == "" {
+		return nil, nil
+	}
+	return policyId, nil
+}
+
+//// UTILITY FUNCTION
+
+// The accessPolicy link of an app points to its authentication policy, e.g.
+// https://{domain}/api/v1/policies/{policyId}
+func getApplicationAccessPolicyId(app *ApplicationStructure) string {
+	links, ok := app.Links.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	accessPolicy, ok := links["accessPolicy"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	href, _ := accessPolicy["href"].(string)
+	if href == "" {
+		return ""
+	}
+	return path.Base(href)
+}
+
+func getApplicationSigningKeyId(app *ApplicationStructure) string {
+	signing, ok := app.Credentials["signing"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	kid, _ := signing["kid"].(string)
+	return kid
+}
+
+var listOktaApplicationsPageMemoized = plugin.HydrateFunc(listOktaApplicationsPageUncached).Memoize(memoize.WithCacheKeyFunction(getListPageCacheKey), memoize.WithTtl(listPageCacheTtl))
+
+// listOktaApplicationsPage returns the page of applications at the URL,
+// shared by the scans of the query listing the same applications
+func listOktaApplicationsPage(ctx context.Context, d *plugin.QueryData, url string) ([]*ApplicationStructure, *okta.Response, error) {
+	page, err := getListPage(ctx, d, url, listOktaApplicationsPageMemoized, listOktaApplicationsPageUncached)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page.items.([]*ApplicationStructure), page.resp, nil
+}
+
+func listOktaApplicationsPageUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var applications []*ApplicationStructure
+
+	resp, err := listOktaPage(ctx, client, h.Item.(string), &applications)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listPage{items: applications, resp: resp}, nil
+}
+
+func getApplicationWithSettings(ctx context.Context, client okta.Client, appId string) (*ApplicationStructure, error) {
+	requestExecutor := client.GetRequestExecutor()
+	req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", fmt.Sprintf("/api/v1/apps/%s", appId), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var application *ApplicationStructure
+
+	_, err = requestExecutor.Do(ctx, req, &application)
+	if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
@@ -2,7 +2,6 @@ package okta
 
 import (
 	"context"
-	"strings"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/okta/okta-sdk-golang/v2/okta/query"
@@ -19,9 +18,9 @@ func tableOktaAuthServer() *plugin.Table {
 		Name:        "okta_auth_server",
 		Description: "Represents an Okta Authorization Server.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaAuthServer,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaAuthServer,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaAuthServers,
@@ -42,6 +41,7 @@ func tableOktaAuthServer() *plugin.Table {
 			{Name: "issuer_mode", Type: proto.ColumnType_STRING, Description: "The issuer mode of the authorization server."},
 			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the authorization server was last updated."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "The status of the authorization server."},
+			{Name: "self_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "self"), Description: "A self-referential link to the authorization server."},
 
 			// JSON Columns
 			{Name: "audiences", Type: proto.ColumnType_JSON, Description: "The audiences of the authorization server."},
@@ -66,59 +66,29 @@ func listOktaAuthServers(ctx context.Context, d *plugin.QueryData, _ *plugin.Hyd
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/authorization-servers/#list-authorization-servers
 	input := query.Params{
-		Limit: 200,
+		Limit: getListPageSize(d, 200),
 	}
 
 	if d.EqualsQualString("name") != "" {
 		input.Q = d.EqualsQualString("name")
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
-	}
-
 	servers, resp, err := client.AuthorizationServer.ListAuthorizationServers(ctx, &input)
 	if err != nil {
 		logger.Error("listOktaAuthServers", "list_auth_servers_error", err)
-		if strings.Contains(err.Error(), "Not found") {
+		if isNotFoundError(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	for _, server := range servers {
-		d.StreamListItem(ctx, server)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextAuthorizationServerSet []*okta.AuthorizationServer
-		resp, err = resp.Next(ctx, &nextAuthorizationServerSet)
-		if err != nil {
-			logger.Error("listOktaAuthServers", "list_auth_servers_paging_error", err)
-			return nil, err
-		}
-		for _, server := range nextAuthorizationServerSet {
-			d.StreamListItem(ctx, server)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
+	err = streamPages(ctx, d, servers, v2Pages[*okta.AuthorizationServer](ctx, resp), nil)
+	if err != nil {
+		logger.Error("listOktaAuthServers", "list_auth_servers_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTIONS
@@ -3,9 +3,8 @@ package okta
 import (
 	"context"
 
-	"github.com/okta/okta-sdk-golang/v2/okta"
-	"github.com/okta/okta-sdk-golang/v2/okta/query"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
@@ -17,8 +16,13 @@ func tableOktaAuthenticationPolicy() *plugin.Table {
 		Name:        "okta_authentication_policy",
 		Description: "Okta Authentication Policy controls the manner in which a user is authenticated, including MFA requirements.",
 		List: &plugin.ListConfig{
-			Hydrate: listOktaAuthenticationPolicies,
+			Hydrate: listPolicies,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "name", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
 		},
+		HydrateConfig: append(policyHydrateConfig(), plugin.HydrateConfig{Func: getOktaAccessPolicyRulesResolved, MaxConcurrency: 10}),
 		Columns: []*plugin.Column{
 			// Top Columns
 			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the Policy."},
@@ -31,10 +35,15 @@ func tableOktaAuthenticationPolicy() *plugin.Table {
 			{Name: "priority", Type: proto.ColumnType_INT, Description: "Priority of the Policy."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "Status of the Policy: ACTIVE or INACTIVE."},
 			{Name: "system", Type: proto.ColumnType_BOOL, Description: "This is set to true on system policies, which cannot be deleted."},
+			{Name: "mfa_required_any_rule", Type: proto.ColumnType_BOOL, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("MfaRequiredAnyRule"), Description: "True if at least one active rule that allows access requires two factors."},
+			{Name: "mfa_required_all_rules", Type: proto.ColumnType_BOOL, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("MfaRequiredAllRules"), Description: "True if every active rule that allows access requires two factors."},
 
 			// JSON Columns
 			{Name: "conditions", Type: proto.ColumnType_JSON, Description: "Conditions for Policy."},
+			{Name: "applies_to", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAppliesTo, Transform: transform.FromValue(), Description: "The groups and users included in or excluded from the Policy, with their IDs resolved to names."},
+			{Name: "network_conditions", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("NetworkConditions"), Description: "The network conditions of the active rules, including the rule name, access, connection type and the network zones included or excluded."},
 			{Name: "rules", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRules, Transform: transform.FromValue(), Description: "Each Policy may contain one or more Rules. Rules, like Policies, contain conditions that must be satisfied for the Rule to be applied."},
+			{Name: "rules_resolved", Type: proto.ColumnType_JSON, Hydrate: getOktaAccessPolicyRulesResolved, Transform: transform.FromValue(), Description: "The rules of the Policy, with the referenced device assurance policies and authenticators resolved to their names."},
 			{Name: "resource_mapping", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAssociatedResources, Transform: transform.FromValue(), Description: "The resources that are mapped to the Policy."},
 
 			// Steampipe Columns
@@ -43,52 +52,165 @@ func tableOktaAuthenticationPolicy() *plugin.Table {
 	}
 }
 
-//// LIST FUNCTION
+//// HYDRATE FUNCTIONS
 
-func listOktaAuthenticationPolicies(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+func getOktaAccessPolicyRulesResolved(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	input := &query.Params{
-		Type: "ACCESS_POLICY",
+	if getPolicyId(h.Item) == "" {
+		return nil, nil
 	}
 
-	client, err := Connect(ctx, d)
+	rules, err := listOktaPolicyRules(ctx, d, h)
 	if err != nil {
-		logger.Error("listOktaAuthenticationPolicies", "connect_error", err)
+		logger.Error("okta_authentication_policy.getOktaAccessPolicyRulesResolved", "list_policy_rules_error", err)
 		return nil, err
 	}
 
-	policies, resp, err := client.Policy.ListPolicies(ctx, input)
+	names, err := getOktaPolicyReferenceNames(ctx, d, h)
 	if err != nil {
-		logger.Error("listOktaAuthenticationPolicies", "list_policies_error", err)
+		logger.Error("okta_authentication_policy.getOktaAccessPolicyRulesResolved", "api_error", err)
 		return nil, err
 	}
+	references := names.(*PolicyReferenceNames)
 
-	for _, policy := range policies {
-		d.StreamListItem(ctx, policy)
+	var resolved []map[string]interface{}
+	for _, rule := range rules {
+		// Round trip through JSON so the rule keeps the API field names
+		var r map[string]interface{}
+		if err := decodeUnionInstance(&rule, &r); err != nil {
+			return nil, err
+		}
+		if r == nil {
+			continue
+		}
 
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
+		// conditions.device.assurance.include holds device assurance policy IDs
+		if assurance := getNestedMap(r, "conditions", "device", "assurance"); assurance != nil {
+			if include, ok := assurance["include"].([]interface{}); ok {
+				var includeNames []interface{}
+				for _, id := range include {
+					includeNames = append(includeNames, map[string]interface{}{"id": id, "name": references.DeviceAssurances[id.(string)]})
+				}
+				assurance["includeResolved"] = includeNames
+			}
 		}
-	}
 
-	// paging
-	for resp.HasNextPage() {
-		var nextPolicySet []*okta.Policy
-		resp, err = resp.Next(ctx, &nextPolicySet)
-		if err != nil {
-			logger.Error("listOktaAuthenticationPolicies", "list_policies_paging_error", err)
-			return nil, err
+		// actions.appSignOn.verificationMethod.constraints reference authenticators by key
+		if verificationMethod := getNestedMap(r, "actions", "appSignOn", "verificationMethod"); verificationMethod != nil {
+			if constraints, ok := verificationMethod["constraints"].([]interface{}); ok {
+				for _, constraint := range constraints {
+					if constraint, ok := constraint.(map[string]interface{}); ok {
+						resolveAuthenticatorNames(constraint, references.Authenticators)
+					}
+				}
+			}
 		}
-		for _, policy := range nextPolicySet {
-			d.StreamListItem(ctx, policy)
 
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
+		resolved = append(resolved, r)
+	}
+
+	return resolved, nil
+}
+
+type PolicyReferenceNames struct {
+	Authenticators   map[string]string
+	DeviceAssurances map[string]string
+}
+
+// if the caching is required other than per connection, build a cache key for the call and use it in Memoize.
+var getOktaPolicyReferenceNamesMemoized = plugin.HydrateFunc(getOktaPolicyReferenceNamesUncached).Memoize(memoize.WithCacheKeyFunction(getOktaPolicyReferenceNamesCacheKey))
+
+// declare a wrapper hydrate function to call the memoized function
+// - this is required when a memoized function is used for a column definition
+func getOktaPolicyReferenceNames(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaPolicyReferenceNamesMemoized(ctx, d, h)
+}
+
+// Build a cache key for the call to getOktaPolicyReferenceNames.
+func getOktaPolicyReferenceNamesCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := "getOktaPolicyReferenceNames"
+	return key, nil
+}
+
+// getOktaPolicyReferenceNamesUncached maps authenticator keys and device
+// assurance policy IDs to their names
+func getOktaPolicyReferenceNamesUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	requestExecutor := client.GetRequestExecutor()
+
+	names := &PolicyReferenceNames{
+		Authenticators:   map[string]string{},
+		DeviceAssurances: map[string]string{},
+	}
+
+	var authenticators []struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	}
+	req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", "/api/v1/authenticators", nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = requestExecutor.Do(ctx, req, &authenticators); err != nil {
+		return nil, err
+	}
+	for _, authenticator := range authenticators {
+		names.Authenticators[authenticator.Key] = authenticator.Name
+	}
+
+	var deviceAssurances []struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	req, err = requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", "/api/v1/device-assurances", nil)
+	if err != nil {
+		return nil, err
+	}
+	// Device assurance requires Identity Engine, so a missing endpoint leaves the IDs unresolved
+	if _, err = requestExecutor.Do(ctx, req, &deviceAssurances); err != nil && !isNotFoundError(err) {
+		return nil, err
+	}
+	for _, deviceAssurance := range deviceAssurances {
+		names.DeviceAssurances[deviceAssurance.Id] = deviceAssurance.Name
+	}
+
+	return names, nil
+}
+
+//// UTILITY FUNCTION
+
+// resolveAuthenticatorNames adds the authenticator name to each authentication
+// method of the knowledge and possession constraints
+func resolveAuthenticatorNames(constraint map[string]interface{}, authenticators map[string]string) {
+	for _, factor := range []string{"knowledge", "possession"} {
+		factorConstraint, ok := constraint[factor].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, methods := range []string{"authenticationMethods", "excludedAuthenticationMethods"} {
+			list, _ := factorConstraint[methods].([]interface{})
+			for _, method := range list {
+				if method, ok := method.(map[string]interface{}); ok {
+					if key, ok := method["key"].(string); ok {
+						method["name"] = authenticators[key]
+					}
+				}
 			}
 		}
 	}
+}
 
-	return nil, err
+// getNestedMap walks a decoded JSON object and returns the object at the given path
+func getNestedMap(m map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, key := range keys {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+	return m
 }
@@ -17,9 +17,9 @@ func tableOktaAuthenticator() *plugin.Table {
 		Name:        "okta_authenticator",
 		Description: "Represents an Okta Authenticator configured in the organization.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaAuthenticator,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaAuthenticator,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaAuthenticators,
@@ -35,6 +35,7 @@ func tableOktaAuthenticator() *plugin.Table {
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "Lifecycle status of the authenticator (ACTIVE or INACTIVE)."},
 			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the authenticator was created."},
 			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the authenticator was last updated."},
+			{Name: "self_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "self"), Description: "A self-referential link to the authenticator."},
 
 			// JSON columns
 			{Name: "settings", Type: proto.ColumnType_JSON, Description: "Settings for the authenticator."},
@@ -64,33 +65,12 @@ func listOktaAuthenticators(ctx context.Context, d *plugin.QueryData, _ *plugin.
 		return nil, err
 	}
 
-	for _, item := range authenticators {
-		if v := item.GetActualInstance(); v != nil {
-			d.StreamListItem(ctx, v)
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
-	}
-
-	// pagination
-	for resp.HasNextPage() {
-		var nextSet []oktaV5.ListAuthenticators200ResponseInner
-		resp, err = resp.Next(&nextSet)
-		if err != nil {
-			logger.Error("okta_authenticator.listOktaAuthenticators", "paging_error", err)
-			return nil, err
-		}
-		for _, item := range nextSet {
-			if v := item.GetActualInstance(); v != nil {
-				d.StreamListItem(ctx, v)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil, nil
-				}
-			}
-		}
+	err = streamPages(ctx, d, authenticators, v5Pages[oktaV5.ListAuthenticators200ResponseInner](resp), func(item oktaV5.ListAuthenticators200ResponseInner) interface{} {
+		return item.GetActualInstance()
+	})
+	if err != nil {
+		logger.Error("okta_authenticator.listOktaAuthenticators", "paging_error", err)
+		return nil, err
 	}
 
 	return nil, nil
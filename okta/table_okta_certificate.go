@@ -0,0 +1,356 @@
+package okta
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/okta/okta-sdk-golang/v2/okta"
+	"github.com/okta/okta-sdk-golang/v2/okta/query"
+	oktaV5 "github.com/okta/okta-sdk-golang/v5/okta"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+const (
+	certificateSourceApplication         = "APPLICATION"
+	certificateSourceIdentityProvider    = "IDENTITY_PROVIDER"
+	certificateSourceCustomDomain        = "CUSTOM_DOMAIN"
+	certificateSourceAuthorizationServer = "AUTHORIZATION_SERVER"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaCertificate() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_certificate",
+		Description: "Certificates and signing keys used by applications, identity providers, custom domains and authorization servers.",
+		List: &plugin.ListConfig{
+			Hydrate: listOktaCertificates,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "source_type", Require: plugin.Optional},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "source_type", Type: proto.ColumnType_STRING, Description: "The type of resource the certificate belongs to. Can be one of APPLICATION, IDENTITY_PROVIDER, CUSTOM_DOMAIN or AUTHORIZATION_SERVER."},
+			{Name: "source_id", Type: proto.ColumnType_STRING, Description: "Unique key of the resource the certificate belongs to."},
+			{Name: "source_name", Type: proto.ColumnType_STRING, Description: "The name of the resource the certificate belongs to."},
+			{Name: "kid", Type: proto.ColumnType_STRING, Description: "The key ID of the certificate."},
+			{Name: "not_after", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the certificate expires. Always null for authorization server keys, as the API reports no expiry for them."},
+			{Name: "days_until_expiry", Type: proto.ColumnType_INT, Transform: transform.FromField("NotAfter").Transform(daysUntil), Description: "The number of days until the certificate expires. Negative if the certificate has already expired. Always null for authorization server keys."},
+
+			// Other Columns
+			{Name: "source_subtype", Type: proto.ColumnType_STRING, Description: "The sign-on mode of the application, or the type of the identity provider."},
+			{Name: "usage", Type: proto.ColumnType_STRING, Description: "How the certificate is used. Can be one of signing, trust or tls."},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "The status of the key, if reported. Can be one of ACTIVE, NEXT or EXPIRED for authorization server keys."},
+			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the certificate was created."},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the certificate was last updated."},
+			{Name: "thumbprint", Type: proto.ColumnType_STRING, Description: "The certificate SHA-256 thumbprint, or the fingerprint of a custom domain certificate."},
+
+			// JSON Columns
+			{Name: "x5c", Type: proto.ColumnType_JSON, Description: "X.509 certificate chain that contains a chain of one or more certificates."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Kid"), Description: titleDescription},
+		}),
+	}
+}
+
+type CertificateInfo struct {
+	SourceType    string
+	SourceId      *string
+	SourceName    *string
+	SourceSubtype *string
+	Kid           *string
+	Usage         string
+	Status        *string
+	NotAfter      *time.Time
+	Created       *time.Time
+	LastUpdated   *time.Time
+	Thumbprint    *string
+	X5c           []string
+
+	// The key or certificate, as returned by the API
+	raw interface{}
+}
+
+func (c CertificateInfo) RawObject() interface{} {
+	return c.raw
+}
+
+//// LIST FUNCTION
+
+func listOktaCertificates(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("okta_certificate.listOktaCertificates", "connect_error", err)
+		return nil, err
+	}
+
+	clientV5, err := ConnectV5(ctx, d)
+	if err != nil {
+		logger.Error("okta_certificate.listOktaCertificates", "connect_v5_error", err)
+		return nil, err
+	}
+
+	sources := []struct {
+		sourceType string
+		list       func(context.Context, *plugin.QueryData, *okta.Client, *oktaV5.APIClient) error
+	}{
+		{certificateSourceApplication, listApplicationCertificates},
+		{certificateSourceIdentityProvider, listIdentityProviderCertificates},
+		{certificateSourceCustomDomain, listCustomDomainCertificates},
+		{certificateSourceAuthorizationServer, listAuthorizationServerCertificates},
+	}
+
+	sourceType := d.EqualsQualString("source_type")
+	for _, source := range sources {
+		if sourceType != "" && sourceType != source.sourceType {
+			continue
+		}
+		if err := source.list(ctx, d, client, clientV5); err != nil {
+			// A source the API token or the service app can't read, e.g. the
+			// identity providers without okta.idps.read, is skipped rather
+			// than failing the certificates of the other sources
+			if shouldSkipCertificateSource(ctx, d, err) {
+				logger.Warn("okta_certificate.listOktaCertificates", "source_type", source.sourceType, "skipping_source", err)
+				continue
+			}
+			logger.Error("okta_certificate.listOktaCertificates", "source_type", source.sourceType, "api_error", err)
+			return nil, err
+		}
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// shouldSkipCertificateSource returns whether the error of a source is ignored
+// by the ignore_error_codes setting or denies access to it, unless the
+// strict_permissions setting keeps the errors
+func shouldSkipCertificateSource(ctx context.Context, d *plugin.QueryData, err error) bool {
+	if shouldIgnoreErrorPluginDefault()(ctx, d, nil, err) {
+		return true
+	}
+	config := GetConfig(d.Connection)
+	if config.StrictPermissions != nil && *config.StrictPermissions {
+		return false
+	}
+	return hasErrorCode(err, forbiddenErrorCodes)
+}
+
+func listApplicationCertificates(ctx context.Context, d *plugin.QueryData, client *okta.Client, clientV5 *oktaV5.APIClient) error {
+	apps, resp, err := client.Application.ListApplications(ctx, &query.Params{Limit: getPageSize(d, 200)})
+	if err != nil {
+		return err
+	}
+
+	var applications []*okta.Application
+	for _, app := range apps {
+		if application, ok := app.(*okta.Application); ok {
+			applications = append(applications, application)
+		}
+	}
+
+	applications, err = collectPages(ctx, applications, v2Pages[*okta.Application](ctx, resp))
+	if err != nil {
+		return err
+	}
+
+	for _, app := range applications {
+		keys, _, err := clientV5.ApplicationCredentialsAPI.ListApplicationKeys(ctx, app.Id).Execute()
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			return err
+		}
+
+		for _, key := range keys {
+			d.StreamListItem(ctx, newCertificateFromJsonWebKey(key, certificateSourceApplication, app.Id, app.Label, app.SignOnMode, "signing"))
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func listIdentityProviderCertificates(ctx context.Context, d *plugin.QueryData, _ *okta.Client, client *oktaV5.APIClient) error {
+	idps, resp, err := client.IdentityProviderAPI.ListIdentityProviders(ctx).Execute()
+	if err != nil {
+		return err
+	}
+
+	idps, err = collectPages(ctx, idps, v5Pages[oktaV5.IdentityProvider](resp))
+	if err != nil {
+		return err
+	}
+
+	// Trust certificates are stored in a shared key store and referenced by kid from each identity provider
+	trustKeys, resp, err := client.IdentityProviderAPI.ListIdentityProviderKeys(ctx).Execute()
+	if err != nil {
+		return err
+	}
+	trustKeys, err = collectPages(ctx, trustKeys, v5Pages[oktaV5.JsonWebKey](resp))
+	if err != nil {
+		return err
+	}
+
+	trustKeyMap := map[string]oktaV5.JsonWebKey{}
+	for _, key := range trustKeys {
+		trustKeyMap[key.GetKid()] = key
+	}
+
+	for _, idp := range idps {
+		credentials := idp.GetProtocol().Credentials
+		if credentials != nil && credentials.Trust != nil {
+			if key, ok := trustKeyMap[credentials.Trust.GetKid()]; ok {
+				d.StreamListItem(ctx, newCertificateFromJsonWebKey(key, certificateSourceIdentityProvider, idp.GetId(), idp.GetName(), idp.GetType(), "trust"))
+
+				// Context can be cancelled due to manual cancellation or the limit has been hit
+				if d.RowsRemaining(ctx) == 0 {
+					return nil
+				}
+			}
+		}
+
+		// Signing keys are only available for SAML 2.0 identity providers
+		if idp.GetType() != "SAML2" {
+			continue
+		}
+		signingKeys, _, err := client.IdentityProviderAPI.ListIdentityProviderSigningKeys(ctx, idp.GetId()).Execute()
+		if err != nil {
+			return err
+		}
+		for _, key := range signingKeys {
+			d.StreamListItem(ctx, newCertificateFromJsonWebKey(key, certificateSourceIdentityProvider, idp.GetId(), idp.GetName(), idp.GetType(), "signing"))
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func listCustomDomainCertificates(ctx context.Context, d *plugin.QueryData, _ *okta.Client, client *oktaV5.APIClient) error {
+	domains, _, err := client.CustomDomainAPI.ListCustomDomains(ctx).Execute()
+	if err != nil {
+		return err
+	}
+
+	for _, domain := range domains.Domains {
+		certificate := domain.PublicCertificate
+		if certificate == nil {
+			continue
+		}
+
+		info := CertificateInfo{
+			SourceType:    certificateSourceCustomDomain,
+			SourceId:      domain.Id,
+			SourceName:    domain.Domain,
+			SourceSubtype: domain.CertificateSourceType,
+			Usage:         "tls",
+			Status:        domain.ValidationStatus,
+			Thumbprint:    certificate.Fingerprint,
+			raw:           certificate,
+		}
+		if certificate.Expiration != nil {
+			if notAfter, err := time.Parse(time.RFC3339, *certificate.Expiration); err == nil {
+				info.NotAfter = &notAfter
+			}
+		}
+		d.StreamListItem(ctx, info)
+
+		// Context can be cancelled due to manual cancellation or the limit has been hit
+		if d.RowsRemaining(ctx) == 0 {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func listAuthorizationServerCertificates(ctx context.Context, d *plugin.QueryData, _ *okta.Client, client *oktaV5.APIClient) error {
+	servers, resp, err := client.AuthorizationServerAPI.ListAuthorizationServers(ctx).Execute()
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	servers, err = collectPages(ctx, servers, v5Pages[oktaV5.AuthorizationServer](resp))
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		keys, _, err := client.AuthorizationServerKeysAPI.ListAuthorizationServerKeys(ctx, server.GetId()).Execute()
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			d.StreamListItem(ctx, CertificateInfo{
+				SourceType: certificateSourceAuthorizationServer,
+				SourceId:   server.Id,
+				SourceName: server.Name,
+				Kid:        key.Kid,
+				Usage:      "signing",
+				Status:     key.Status,
+				raw:        key,
+			})
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+//// TRANSFORM FUNCTION
+
+func daysUntil(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	t, ok := d.Value.(*time.Time)
+	if !ok || t == nil {
+		return nil, nil
+	}
+	return int64(math.Floor(time.Until(*t).Hours() / 24)), nil
+}
+
+//// UTILITY FUNCTION
+
+func newCertificateFromJsonWebKey(key oktaV5.JsonWebKey, sourceType, sourceId, sourceName, sourceSubtype, usage string) CertificateInfo {
+	return CertificateInfo{
+		SourceType:    sourceType,
+		SourceId:      &sourceId,
+		SourceName:    &sourceName,
+		SourceSubtype: &sourceSubtype,
+		Kid:           key.Kid,
+		Usage:         usage,
+		Status:        key.Status,
+		NotAfter:      key.ExpiresAt,
+		Created:       key.Created,
+		LastUpdated:   key.LastUpdated,
+		Thumbprint:    key.X5tS256,
+		X5c:           key.X5c,
+		raw:           key,
+	}
+}
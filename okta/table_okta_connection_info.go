@@ -0,0 +1,144 @@
+package okta
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaConnectionInfo() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_connection_info",
+		Description: "Diagnostic information about the Okta connection, including the resolved org, authentication mode and the most recently observed rate limits.",
+		List: &plugin.ListConfig{
+			Hydrate: listOktaConnectionInfo,
+		},
+		// The rate limits change with every request, so results are never
+		// served from the query cache
+		Cache: &plugin.TableCacheOptions{
+			Enabled: false,
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "org_url", Type: proto.ColumnType_STRING, Description: "The Okta org URL used by the connection."},
+			{Name: "org_id", Type: proto.ColumnType_STRING, Description: "The unique identifier of the Okta org."},
+			{Name: "auth_mode", Type: proto.ColumnType_STRING, Description: "The authorization mode used by the connection. Can be SSWS (API token), PrivateKey (OAuth 2.0 service app) or Bearer (access token)."},
+
+			// Other Columns
+			{Name: "pipeline", Type: proto.ColumnType_STRING, Description: "The authentication pipeline of the org. idx means the org is using the Identity Engine, while v1 means the org is using the Classic Engine."},
+			{Name: "client_id", Type: proto.ColumnType_STRING, Description: "The client ID of the service app, if the connection uses OAuth 2.0."},
+			{Name: "sdk_version", Type: proto.ColumnType_STRING, Description: "The version of the Okta SDK used by the plugin."},
+			{Name: "user_agent", Type: proto.ColumnType_STRING, Description: "The user agent sent with each API request."},
+			{Name: "request_timeout", Type: proto.ColumnType_INT, Description: "The time out, in seconds, of each attempt of an HTTP request."},
+			{Name: "max_retries", Type: proto.ColumnType_INT, Description: "The maximum number of times failed API calls are retried."},
+			{Name: "max_backoff", Type: proto.ColumnType_INT, Description: "The maximum amount of time, in seconds, to wait on request back off."},
+			{Name: "rate_limit_endpoint", Type: proto.ColumnType_STRING, Description: "The endpoint of the most recent API call of the connection, by any table, whose rate limit is reported, e.g. /api/v1/users/{id}/factors. Null if the connection made no API call yet."},
+			{Name: "rate_limit_limit", Type: proto.ColumnType_INT, Description: "The rate limit ceiling reported in the X-Rate-Limit-Limit header of the most recent response to an API call of the connection."},
+			{Name: "rate_limit_remaining", Type: proto.ColumnType_INT, Description: "The number of requests left for the current rate-limit window, as reported in the X-Rate-Limit-Remaining header of the most recent response to an API call of the connection."},
+			{Name: "rate_limit_reset", Type: proto.ColumnType_TIMESTAMP, Description: "The time at which the current rate limit window resets, as reported in the X-Rate-Limit-Reset header of the most recent response to an API call of the connection."},
+
+			// JSON Columns
+			{Name: "scopes", Type: proto.ColumnType_JSON, Description: "The OAuth 2.0 scopes the connection requests for its access tokens, from the scopes setting or the defaults, if the connection uses OAuth 2.0. These are the configured scopes rather than those granted to the service app."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("OrgUrl"), Description: titleDescription},
+		}),
+	}
+}
+
+type ConnectionInfo struct {
+	OrgUrl             string
+	OrgId              *string
+	AuthMode           string
+	Pipeline           *string
+	ClientId           *string
+	SdkVersion         string
+	UserAgent          string
+	RequestTimeout     int64
+	MaxRetries         int32
+	MaxBackoff         int64
+	RateLimitEndpoint  *string
+	RateLimitLimit     *int
+	RateLimitRemaining *int
+	RateLimitReset     *time.Time
+	Scopes             []string
+
+	// The well-known metadata of the org
+	raw interface{}
+}
+
+func (i ConnectionInfo) RawObject() interface{} {
+	return i.raw
+}
+
+//// LIST FUNCTION
+
+func listOktaConnectionInfo(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	client, err := ConnectV5(ctx, d)
+	if err != nil {
+		logger.Error("okta_connection_info.listOktaConnectionInfo", "connect_error", err)
+		return nil, err
+	}
+
+	// The SDK clients are given the timeout of whole calls, including retries,
+	// rather than the request_timeout of each attempt
+	clientConfig, err := getOktaConfigValues(d.Connection)
+	if err != nil {
+		logger.Error("okta_connection_info.listOktaConnectionInfo", "config_error", err)
+		return nil, err
+	}
+
+	config := client.GetConfig()
+	info := ConnectionInfo{
+		OrgUrl:         config.Okta.Client.OrgUrl,
+		AuthMode:       config.Okta.Client.AuthorizationMode,
+		UserAgent:      config.UserAgent,
+		RequestTimeout: clientConfig.requestTimeout,
+		MaxRetries:     config.Okta.Client.RateLimit.MaxRetries,
+		MaxBackoff:     config.Okta.Client.RateLimit.MaxBackoff,
+	}
+
+	// The user agent is of the form "okta-sdk-golang/<version> golang/<go version> <os>/<arch>"
+	sdk := strings.Fields(config.UserAgent)
+	if len(sdk) > 0 {
+		if _, version, ok := strings.Cut(sdk[0], "/"); ok {
+			info.SdkVersion = version
+		}
+	}
+
+	if info.AuthMode == "PrivateKey" {
+		info.ClientId = &config.Okta.Client.ClientId
+		info.Scopes = config.Okta.Client.Scopes
+	}
+
+	// The rate limits are those of the last call of the connection, e.g. of
+	// another table of the query, read before the org metadata call below,
+	// which has a bucket of its own
+	if limit, ok := getObservedRateLimit(d.Connection.Name); ok {
+		info.RateLimitEndpoint = &limit.endpoint
+		info.RateLimitLimit = &limit.limit
+		info.RateLimitRemaining = &limit.remaining
+		info.RateLimitReset = &limit.reset
+	}
+
+	// The well-known org metadata endpoint resolves the org ID without requiring any additional scopes
+	metadata, _, err := client.OrgSettingAPI.GetWellknownOrgMetadata(ctx).Execute()
+	if err != nil {
+		logger.Error("okta_connection_info.listOktaConnectionInfo", "api_error", err)
+		return nil, err
+	}
+	info.OrgId = metadata.Id
+	info.Pipeline = metadata.Pipeline
+	info.raw = metadata
+
+	d.StreamListItem(ctx, info)
+
+	return nil, nil
+}
@@ -17,9 +17,9 @@ func tableOktaDevice() *plugin.Table {
 		Name:        "okta_device",
 		Description: "Okta’s device management is a crucial part of its broader suite of identity and access management solutions, helping organizations to secure their IT environments in an increasingly mobile and cloud-centric world.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaDevice,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaDevice,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaDevices,
@@ -73,7 +73,7 @@ func tableOktaDevice() *plugin.Table {
 			{Name: "display_name", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.DisplayName"), Description: "Display name of the device."},
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for the device."},
 			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when device was created."},
-			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the device record was last updated.", Transform: transform.FromField("LastUpdated").Transform(transform.NullIfZeroValue)},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the device record was last updated.", Transform: transform.FromField("LastUpdated")},
 			{Name: "resource_id", Type: proto.ColumnType_STRING, Description: "Alternate key for the Id."},
 			{Name: "resource_type", Type: proto.ColumnType_STRING, Description: "The resource type."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "The state object of the device."},
@@ -84,6 +84,8 @@ func tableOktaDevice() *plugin.Table {
 			{Name: "sid", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.Sid"), Description: "Windows Security identifier of the device."},
 			{Name: "udid", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.Udid"), Description: "macOS Unique Device identifier of the device."},
 			{Name: "imei", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.Imei"), Description: "International Mobile Equipment Identity (IMEI) of the device"},
+			{Name: "self_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "self"), Description: "A self-referential link to the device."},
+			{Name: "users_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "users"), Description: "The API URL listing the users of the device."},
 
 			// JSON Columns
 			{Name: "profile", Type: proto.ColumnType_JSON, Description: "The Device's Profile properties."},
@@ -110,15 +112,7 @@ func listOktaDevices(ctx context.Context, d *plugin.QueryData, _ *plugin.Hydrate
 
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Device/#tag/Device/operation/listDevices!in=query&path=limit&t=request
-	maxLimit := int64(20)
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < maxLimit {
-			maxLimit = *limit
-		}
-	}
+	maxLimit := getListPageSize(d, 20)
 
 	searchParam := buildDeviceFilterParam(d)
 
@@ -134,34 +128,13 @@ func listOktaDevices(ctx context.Context, d *plugin.QueryData, _ *plugin.Hydrate
 		return nil, err
 	}
 
-	for _, device := range devices {
-		d.StreamListItem(ctx, device)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextDeviceSet []okta.DeviceList
-		resp, err = resp.Next(&nextDeviceSet)
-		if err != nil {
-			logger.Error("okta_device.listOktaDevices", "paging_error", err)
-			return nil, err
-		}
-		for _, device := range nextDeviceSet {
-			d.StreamListItem(ctx, device)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
+	err = streamPages(ctx, d, devices, v4Pages[okta.DeviceList](resp), nil)
+	if err != nil {
+		logger.Error("okta_device.listOktaDevices", "paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTIONS
@@ -3,7 +3,6 @@ package okta
 import (
 	"context"
 	"slices"
-	"strings"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	oktav4 "github.com/okta/okta-sdk-golang/v4/okta"
@@ -21,22 +20,24 @@ func tableOktaFactor() *plugin.Table {
 		Name:        "okta_factor",
 		Description: "Represents an Okta Factor.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaFactor,
-			KeyColumns:        plugin.AllColumns([]string{"id", "user_id"}),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found", "Invalid Factor"}),
+			Hydrate:      getOktaFactor,
+			KeyColumns:   plugin.AllColumns([]string{"id", "user_id"}),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreFactorNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			ParentHydrate: listOktaUsers,
 			Hydrate:       listOktaFactors,
 			KeyColumns: []*plugin.KeyColumn{
 				{Name: "user_id", Require: plugin.Optional},
+				{Name: "factor_type", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
 			},
 		},
 		Columns: commonColumns([]*plugin.Column{
 			// Top Columns
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for Group.", Transform: transform.FromField("Factor.Id")},
 			{Name: "user_id", Type: proto.ColumnType_STRING, Description: "Unique key for Group."},
-			{Name: "user_name", Type: proto.ColumnType_STRING, Description: "Unique identifier for the user (username)."},
+			{Name: "user_name", Type: proto.ColumnType_STRING, Hydrate: getOktaFactorUserName, Transform: transform.FromValue(), Description: "Unique identifier for the user (username)."},
 			{Name: "factor_type", Type: proto.ColumnType_STRING, Description: "Description of the Group.", Transform: transform.FromField("Factor.FactorType")},
 			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when Group was created.", Transform: transform.FromField("Factor.Created")},
 
@@ -45,6 +46,15 @@ func tableOktaFactor() *plugin.Table {
 			{Name: "provider", Type: proto.ColumnType_STRING, Description: "The provider for the factor.", Transform: transform.FromField("Factor.Provider")},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "The current status of the factor.", Transform: transform.FromField("Factor.Status")},
 
+			{Name: "device_name", Type: proto.ColumnType_STRING, Description: "Name of the device of a push factor, or of the authenticator of a WebAuthn factor.", Transform: transform.From(factorDeviceName)},
+			{Name: "device_type", Type: proto.ColumnType_STRING, Description: "Type of the device of a push factor, e.g. SmartPhone_IPhone.", Transform: transform.FromField("Factor.Profile.deviceType")},
+			{Name: "platform", Type: proto.ColumnType_STRING, Description: "Platform of the device of a push factor, e.g. IOS or ANDROID.", Transform: transform.FromField("Factor.Profile.platform")},
+			{Name: "credential_id", Type: proto.ColumnType_STRING, Description: "ID of the credential of a push, WebAuthn, U2F or token factor.", Transform: transform.FromField("Factor.Profile.credentialId")},
+			{Name: "device_version", Type: proto.ColumnType_STRING, Description: "Version of the operating system of the device of a push factor.", Transform: transform.FromField("Factor.Profile.version")},
+			{Name: "activation_expires_at", Type: proto.ColumnType_TIMESTAMP, Description: "Time when the pending activation of the factor expires.", Transform: transform.FromField("Factor.Embedded.activation.expiresAt")},
+			{Name: "activation_has_qr_code", Type: proto.ColumnType_BOOL, Description: "True if the pending activation of the factor provides a QR code to enroll the device.", Transform: transform.From(factorActivationHasQrCode)},
+			{Name: "hardware_token_serial", Type: proto.ColumnType_STRING, Description: "Serial number of the hardware token, e.g. a YubiKey, of a token:hardware factor.", Transform: transform.From(factorHardwareTokenSerial)},
+
 			// JSON Columns
 			{Name: "profile", Type: proto.ColumnType_JSON, Description: "Specific attributes related to the Factor.", Transform: transform.FromField("Factor.Profile")},
 			{Name: "embedded", Type: proto.ColumnType_JSON, Description: "The Group's Profile properties.", Transform: transform.FromField("Factor.Embedded")},
@@ -60,6 +70,13 @@ type UserFactorInfo struct {
 	UserId   string
 	UserName string
 	Factor   OktaFactor
+
+	// The factor of its actual type, as returned by the API
+	raw interface{}
+}
+
+func (i UserFactorInfo) RawObject() interface{} {
+	return i.raw
 }
 
 type OktaFactor struct {
@@ -103,60 +120,56 @@ func listOktaFactors(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrate
 		return nil, nil
 	}
 
+	// Steampipe lists the factors of every parent user in its own goroutine,
+	// bound the number of concurrent calls so large orgs stay within the
+	// rate limit rather than retrying on 429 responses
+	concurrency := defaultFactorListConcurrency
+	if config := GetConfig(d.Connection); config.FactorListConcurrency != nil && *config.FactorListConcurrency > 0 {
+		concurrency = *config.FactorListConcurrency
+	}
+	release, err := acquireConnectionSemaphore(ctx, d, "factor_list", concurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// The limit may have been reached while waiting for the other users
+	if d.RowsRemaining(ctx) == 0 {
+		return nil, nil
+	}
+
 	factorReq := client.UserFactorAPI.ListFactors(ctx, userId)
 
 	factors, resp, err := factorReq.Execute()
 	if err != nil {
 		logger.Error("okta_factor.listOktaFactors", "api_error", err)
-		if strings.Contains(err.Error(), "Not found") {
+		if isNotFoundError(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	for _, factor := range factors {
-		if factor.GetActualInstance() != nil {
-			factorDetails := getFactorDetails(factor.GetActualInstance())
-			d.StreamListItem(ctx, UserFactorInfo{
-				UserId:   userId,
-				UserName: userName,
-				Factor:   factorDetails,
-			})
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+	err = streamPages(ctx, d, factors, v4Pages[oktav4.ListFactors200ResponseInner](resp), func(factor oktav4.ListFactors200ResponseInner) interface{} {
+		if factor.GetActualInstance() == nil {
+			return nil
 		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextFactorSet []oktav4.ListFactors200ResponseInner
-		resp, err = resp.Next(&nextFactorSet)
-		if err != nil {
-			logger.Error("okta_factor.listOktaFactors", "api_paging_error", err)
-			return nil, err
+		factorDetails := getFactorDetails(factor.GetActualInstance())
+		if !factorMatchesQuals(d, factorDetails) {
+			return nil
 		}
-
-		for _, factor := range nextFactorSet {
-			if factor.GetActualInstance() != nil {
-				f := getFactorDetails(factor.GetActualInstance())
-				d.StreamListItem(ctx, UserFactorInfo{
-					UserId:   userId,
-					UserName: userName,
-					Factor:   f,
-				})
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil, nil
-				}
-			}
+		return UserFactorInfo{
+			UserId:   userId,
+			UserName: userName,
+			Factor:   factorDetails,
+			raw:      factor.GetActualInstance(),
 		}
+	})
+	if err != nil {
+		logger.Error("okta_factor.listOktaFactors", "api_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTIONS
@@ -176,19 +189,6 @@ func getOktaFactor(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDa
 		return nil, err
 	}
 
-	userReq := client.UserAPI.GetUser(ctx, userId)
-	user, _, err := userReq.Execute()
-	if err != nil {
-		logger.Error("okta_factor.getOktaFactor", "GetUser", err)
-		if strings.Contains(err.Error(), "Not found") {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	userProfile := *user.Profile
-	userName := userProfile.Login
-
 	factorReq := client.UserFactorAPI.GetFactor(ctx, userId, factorId)
 	result, _, err := factorReq.Execute()
 	if err != nil {
@@ -201,75 +201,120 @@ func getOktaFactor(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDa
 	}
 	f := getFactorDetails(result.GetActualInstance())
 
-	return &UserFactorInfo{UserId: userId, UserName: *userName, Factor: f}, nil
+	return &UserFactorInfo{UserId: userId, Factor: f, raw: result.GetActualInstance()}, nil
+}
+
+// The user name is only known when listing the factors of the parent users,
+// so only look the user up when a factor get selects the column
+func getOktaFactorUserName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	factor := getUserFactorInfo(h.Item)
+
+	if factor.UserName != "" || factor.UserId == "" {
+		return factor.UserName, nil
+	}
+
+	client, err := ConnectV4(ctx, d)
+	if err != nil {
+		plugin.Logger(ctx).Error("okta_factor.getOktaFactorUserName", "connection_error", err)
+		return nil, err
+	}
+
+	user, _, err := client.UserAPI.GetUser(ctx, factor.UserId).Execute()
+	if err != nil {
+		plugin.Logger(ctx).Error("okta_factor.getOktaFactorUserName", "api_error", err)
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if user.Profile == nil {
+		return nil, nil
+	}
+	return user.Profile.Login, nil
+}
+
+//// TRANSFORM FUNCTION
+
+// Push factors name the device, WebAuthn factors name the authenticator
+func factorDeviceName(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	factor := getUserFactorInfo(d.HydrateItem)
+
+	profile, ok := factor.Factor.Profile.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	if name, ok := profile["name"]; ok {
+		return name, nil
+	}
+	return profile["authenticatorName"], nil
+}
+
+func factorActivationHasQrCode(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	factor := getUserFactorInfo(d.HydrateItem)
+
+	activation, ok := factor.Factor.Embedded["activation"]
+	if !ok {
+		return nil, nil
+	}
+	links, _ := activation["_links"].(map[string]interface{})
+	_, ok = links["qrcode"]
+	return ok, nil
+}
+
+// Hardware tokens such as YubiKeys use their serial number as credential id
+func factorHardwareTokenSerial(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	factor := getUserFactorInfo(d.HydrateItem)
+
+	if factor.Factor.GetFactorType() != "token:hardware" {
+		return nil, nil
+	}
+	profile, ok := factor.Factor.Profile.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return profile["credentialId"], nil
 }
 
 //// UTILITY FUNCTION
 
+// Default number of users whose factors are listed concurrently
+const defaultFactorListConcurrency = 10
+
+// List returns UserFactorInfo rows while get returns a pointer
+func getUserFactorInfo(item interface{}) UserFactorInfo {
+	switch item := item.(type) {
+	case UserFactorInfo:
+		return item
+	case *UserFactorInfo:
+		return *item
+	}
+	return UserFactorInfo{}
+}
+
+// The factors API has no filter, so check the factor_type and status quals
+// before streaming the factor
+func factorMatchesQuals(d *plugin.QueryData, f OktaFactor) bool {
+	if factorType := d.EqualsQualString("factor_type"); factorType != "" && factorType != f.GetFactorType() {
+		return false
+	}
+	if status := d.EqualsQualString("status"); status != "" && status != f.GetStatus() {
+		return false
+	}
+	return true
+}
+
 func getFactorDetails(i interface{}) OktaFactor {
 	f := OktaFactor{}
 
-	switch item := i.(type) {
-	case *oktav4.UserFactorCall:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorCustomHOTP:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorEmail:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorHardware:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorPush:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorSMS:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorSecurityQuestion:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorTOTP:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorToken:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorU2F:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorWeb:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
-	case *oktav4.UserFactorWebAuthn:
-		f = OktaFactor{
-			item.UserFactor,
-			item.Profile,
-		}
+	// Every factor type shares the UserFactor fields and has its own profile
+	var details struct {
+		Profile interface{} `json:"profile"`
 	}
+	if err := decodeUnionInstance(i, &f.UserFactor, &details); err != nil {
+		return OktaFactor{}
+	}
+	f.Profile = details.Profile
+
 	return f
 }
@@ -0,0 +1,139 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaGovernanceGrant() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_governance_grant",
+		Description: "Okta Identity Governance grants assign entitlements or entitlement bundles of an application to users, optionally for a limited time.",
+		List: &plugin.ListConfig{
+			ParentHydrate: getOrListOktaApplications,
+			Hydrate:       listOktaGovernanceGrants,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "app_id", Require: plugin.Optional},
+				{Name: "principal_id", Require: plugin.Optional},
+				{Name: "grant_type", Require: plugin.Optional},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for the grant."},
+			{Name: "app_id", Type: proto.ColumnType_STRING, Description: "Unique key for the application the grant belongs to."},
+			{Name: "principal_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("TargetPrincipal.ExternalId"), Description: "Unique key for the user the grant is assigned to."},
+			{Name: "grant_type", Type: proto.ColumnType_STRING, Description: "The type of the grant: CUSTOM, ENTITLEMENT-BUNDLE or POLICY."},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "Status of the grant: ACTIVE or INACTIVE."},
+
+			// Other Columns
+			{Name: "principal_orn", Type: proto.ColumnType_STRING, Transform: transform.FromField("TargetPrincipal.Orn"), Description: "The Okta resource name (ORN) of the user the grant is assigned to."},
+			{Name: "principal_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("TargetPrincipal.Type"), Description: "The type of the principal the grant is assigned to."},
+			{Name: "entitlement_bundle_id", Type: proto.ColumnType_STRING, Description: "Unique key for the entitlement bundle granted, if the grant type is ENTITLEMENT-BUNDLE."},
+			{Name: "granted_at", Type: proto.ColumnType_TIMESTAMP, Transform: transform.FromField("Created"), Description: "Timestamp when the grant was created."},
+			{Name: "granted_by", Type: proto.ColumnType_STRING, Transform: transform.FromField("CreatedBy"), Description: "The ID of the user who created the grant."},
+			{Name: "expiration_date", Type: proto.ColumnType_TIMESTAMP, Transform: transform.FromField("ScheduleSettings.ExpirationDate"), Description: "Timestamp when the grant expires. Null if the grant does not expire."},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the grant was last updated."},
+			{Name: "last_updated_by", Type: proto.ColumnType_STRING, Description: "The ID of the user who last updated the grant."},
+
+			// JSON Columns
+			{Name: "entitlements", Type: proto.ColumnType_JSON, Description: "The entitlements and values granted, if the grant type is CUSTOM."},
+			{Name: "target", Type: proto.ColumnType_JSON, Description: "The resource the grant applies to."},
+			{Name: "schedule_settings", Type: proto.ColumnType_JSON, Description: "The schedule of the grant, including when it expires."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Id"), Description: titleDescription},
+		}),
+	}
+}
+
+type GovernanceGrant struct {
+	AppId               string
+	Id                  string                   `json:"id,omitempty"`
+	GrantType           string                   `json:"grantType,omitempty"`
+	Status              string                   `json:"status,omitempty"`
+	EntitlementBundleId string                   `json:"entitlementBundleId,omitempty"`
+	Entitlements        []map[string]interface{} `json:"entitlements,omitempty"`
+	Target              GovernanceResource       `json:"target"`
+	TargetPrincipal     GovernancePrincipal      `json:"targetPrincipal"`
+	ScheduleSettings    *struct {
+		ExpirationDate *time.Time `json:"expirationDate,omitempty"`
+		TimeZone       string     `json:"timeZone,omitempty"`
+	} `json:"scheduleSettings,omitempty"`
+	Created       *time.Time `json:"created,omitempty"`
+	CreatedBy     string     `json:"createdBy,omitempty"`
+	LastUpdated   *time.Time `json:"lastUpdated,omitempty"`
+	LastUpdatedBy string     `json:"lastUpdatedBy,omitempty"`
+}
+
+//// LIST FUNCTION
+
+func listOktaGovernanceGrants(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	app, ok := h.Item.(*ApplicationStructure)
+	if !ok || app == nil {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("okta_governance_grant.listOktaGovernanceGrants", "connect_error", err)
+		return nil, err
+	}
+
+	// The grants API requires a filter on the target resource
+	filter := []string{
+		fmt.Sprintf("target.externalId eq \"%s\"", app.Id),
+		"target.type eq \"APPLICATION\"",
+	}
+	if principalId := d.EqualsQualString("principal_id"); principalId != "" {
+		filter = append(filter, fmt.Sprintf("targetPrincipal.externalId eq \"%s\"", principalId))
+		filter = append(filter, "targetPrincipal.type eq \"OKTA_USER\"")
+	}
+
+	// https://developer.okta.com/docs/api/iga/openapi/governance.api/tag/Grants/
+	params := url.Values{}
+	params.Set("filter", strings.Join(filter, " AND "))
+	params.Set("limit", strconv.FormatInt(getPageSize(d, 200), 10))
+
+	grantType := d.EqualsQualString("grant_type")
+	err = listGovernanceResources(ctx, client, "/governance/api/v1/grants", params, func(data json.RawMessage) (bool, error) {
+		var grants []GovernanceGrant
+		if err := json.Unmarshal(data, &grants); err != nil {
+			return false, err
+		}
+		for _, grant := range grants {
+			if grantType != "" && grant.GrantType != grantType {
+				continue
+			}
+			grant.AppId = app.Id
+			d.StreamListItem(ctx, grant)
+
+			// Context can be cancelled due to manual cancellation or the limit has been hit
+			if d.RowsRemaining(ctx) == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("okta_governance_grant.listOktaGovernanceGrants", "api_error", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
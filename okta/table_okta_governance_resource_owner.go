@@ -0,0 +1,181 @@
+package okta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaGovernanceResourceOwner() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_governance_resource_owner",
+		Description: "Okta Identity Governance resource owners are the users or groups responsible for reviewing access to an application and its resources.",
+		List: &plugin.ListConfig{
+			ParentHydrate: getOrListOktaApplications,
+			Hydrate:       listOktaGovernanceResourceOwners,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "app_id", Require: plugin.Optional},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "resource_orn", Type: proto.ColumnType_STRING, Transform: transform.FromField("Resource.Orn"), Description: "The Okta resource name (ORN) of the governed resource."},
+			{Name: "resource_name", Type: proto.ColumnType_STRING, Transform: transform.FromField("Resource.Profile.name"), Description: "The name of the governed resource."},
+			{Name: "owner_orn", Type: proto.ColumnType_STRING, Transform: transform.FromField("Owner.Orn"), Description: "The Okta resource name (ORN) of the owner."},
+			{Name: "owner_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Owner.ExternalId"), Description: "Unique key for the user or group that owns the resource."},
+			{Name: "owner_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("Owner.Type"), Description: "The type of the owner: OKTA_USER or OKTA_GROUP."},
+
+			// Other Columns
+			{Name: "app_id", Type: proto.ColumnType_STRING, Description: "Unique key for the application the resource belongs to."},
+			{Name: "resource_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Resource.ExternalId"), Description: "Unique key for the governed resource."},
+			{Name: "resource_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("Resource.Type"), Description: "The type of the governed resource, for example APPLICATION, GROUP or ENTITLEMENT_BUNDLE."},
+			{Name: "owner_name", Type: proto.ColumnType_STRING, Transform: transform.From(governanceOwnerName), Description: "The login of the user or the name of the group that owns the resource."},
+
+			// JSON Columns
+			{Name: "resource", Type: proto.ColumnType_JSON, Description: "The governed resource."},
+			{Name: "owner", Type: proto.ColumnType_JSON, Description: "The owner of the resource. Null if the resource has no owner."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Resource.Orn"), Description: titleDescription},
+		}),
+	}
+}
+
+type GovernancePrincipal struct {
+	ExternalId string                 `json:"externalId,omitempty"`
+	Orn        string                 `json:"orn,omitempty"`
+	Type       string                 `json:"type,omitempty"`
+	Profile    map[string]interface{} `json:"profile,omitempty"`
+}
+
+type GovernanceResource struct {
+	ExternalId string                 `json:"externalId,omitempty"`
+	Orn        string                 `json:"orn,omitempty"`
+	Type       string                 `json:"type,omitempty"`
+	Profile    map[string]interface{} `json:"profile,omitempty"`
+}
+
+type GovernanceResourceOwner struct {
+	AppId    string
+	Resource GovernanceResource
+	Owner    *GovernancePrincipal
+}
+
+// The API lists the owners of each resource together, rows have one of them,
+// or none for a resource without an owner
+func (o GovernanceResourceOwner) RawObject() interface{} {
+	return map[string]interface{}{
+		"principal": o.Owner,
+		"resource":  o.Resource,
+	}
+}
+
+//// LIST FUNCTION
+
+func listOktaGovernanceResourceOwners(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	app, ok := h.Item.(*ApplicationStructure)
+	if !ok || app == nil {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("okta_governance_resource_owner.listOktaGovernanceResourceOwners", "connect_error", err)
+		return nil, err
+	}
+
+	appOrn, err := getApplicationOrn(ctx, d, h, app)
+	if err != nil {
+		logger.Error("okta_governance_resource_owner.listOktaGovernanceResourceOwners", "orn_error", err)
+		return nil, err
+	}
+
+	// https://developer.okta.com/docs/api/iga/openapi/governance.api/tag/Resource-Owners/
+	params := url.Values{}
+	params.Set("filter", fmt.Sprintf("parentResourceOrn eq \"%s\"", appOrn))
+
+	err = listGovernanceResources(ctx, client, "/governance/api/v1/resource-owners", params, func(data json.RawMessage) (bool, error) {
+		var items []struct {
+			Principals []GovernancePrincipal `json:"principals"`
+			Resource   GovernanceResource    `json:"resource"`
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return false, err
+		}
+		for _, item := range items {
+			// A resource without an owner has a row with null owner columns
+			owners := []*GovernancePrincipal{nil}
+			if len(item.Principals) > 0 {
+				owners = owners[:0]
+				for i := range item.Principals {
+					owners = append(owners, &item.Principals[i])
+				}
+			}
+			for _, owner := range owners {
+				d.StreamListItem(ctx, GovernanceResourceOwner{
+					AppId:    app.Id,
+					Resource: item.Resource,
+					Owner:    owner,
+				})
+
+				// Context can be cancelled due to manual cancellation or the limit has been hit
+				if d.RowsRemaining(ctx) == 0 {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("okta_governance_resource_owner.listOktaGovernanceResourceOwners", "api_error", err)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+//// TRANSFORM FUNCTION
+
+func governanceOwnerName(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	owner := d.HydrateItem.(GovernanceResourceOwner).Owner
+	if owner == nil {
+		return nil, nil
+	}
+	for _, key := range []string{"login", "name", "displayName"} {
+		if v, ok := owner.Profile[key]; ok {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+//// UTILITY FUNCTION
+
+// Build the Okta resource name (ORN) of an application, of the form
+// orn:{partition}:idp:{orgId}:apps:{appName}:{appId}
+func getApplicationOrn(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData, app *ApplicationStructure) (string, error) {
+	orgId, err := getOktaOrgId(ctx, d, h)
+	if err != nil {
+		return "", err
+	}
+
+	partition := "okta"
+	domain, err := getOktaDomainName(ctx, d, h)
+	if err == nil && strings.HasSuffix(strings.TrimSuffix(domain.(string), "/"), "oktapreview.com") {
+		partition = "oktapreview"
+	}
+
+	return fmt.Sprintf("orn:%s:idp:%s:apps:%s:%s", partition, orgId, app.Name, app.Id), nil
+}
@@ -3,11 +3,15 @@ package okta
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
+	"time"
 
+	"github.com/ettle/strcase"
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/okta/okta-sdk-golang/v2/okta/query"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -15,22 +19,24 @@ import (
 
 //// TABLE DEFINITION
 
-func tableOktaGroup() *plugin.Table {
+func tableOktaGroup(ctx context.Context, td *plugin.TableMapData) *plugin.Table {
 	return &plugin.Table{
 		Name:        "okta_group",
 		Description: "A Group is made up of users. Groups are useful for representing roles, relationships, and can even be used for subscription tiers.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaGroup,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaGroup,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaGroups,
 			KeyColumns: plugin.KeyColumnSlice{
 				// Key fields
 				{Name: "id", Require: plugin.Optional},
+				{Name: "name", Require: plugin.Optional},
 				{Name: "type", Require: plugin.Optional},
 				{Name: "filter", Require: plugin.Optional},
+				{Name: "search", Require: plugin.Optional},
 				{Name: "last_updated", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
 				{Name: "last_membership_updated", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
 			},
@@ -40,68 +46,95 @@ func tableOktaGroup() *plugin.Table {
 				Func:           listGroupMembers,
 				MaxConcurrency: 10,
 			},
+			{
+				Func:           getGroupSourceApp,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           listGroupAssignedRoles,
+				MaxConcurrency: 10,
+			},
 		},
-		Columns: commonColumns([]*plugin.Column{
+		Columns: commonColumns(append([]*plugin.Column{
 			// Top Columns
-			{Name: "name", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.Name"), Description: "Name of the Group."},
+			{Name: "name", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.name"), Description: "Name of the Group."},
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for Group."},
-			{Name: "description", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.Description"), Description: "Description of the Group."},
+			{Name: "description", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.description"), Description: "Description of the Group."},
 			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when Group was created."},
 
 			// Other Columns
 			{Name: "filter", Type: proto.ColumnType_STRING, Transform: transform.FromQual("filter"), Description: "Filter string to [filter](https://developer.okta.com/docs/reference/api/users/#list-users-with-a-filter) users. Input filter query should not be encoded."},
+			{Name: "search", Type: proto.ColumnType_STRING, Transform: transform.FromQual("search"), Description: "Search expression to [search](https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroups!in=query&path=search&t=request) groups, including on profile attributes. Input search query should not be encoded."},
 			{Name: "last_membership_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when Group's memberships were last updated."},
 			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when Group's profile was last updated."},
 			{Name: "type", Type: proto.ColumnType_STRING, Description: "Determines how a Group's Profile and memberships are managed. Can be one of OKTA_GROUP, APP_GROUP or BUILT_IN."},
+			{Name: "members_count", Type: proto.ColumnType_INT, Transform: transform.FromField("Embedded.stats.usersCount"), Description: "The number of users that are a member of the Group."},
+			{Name: "apps_count", Type: proto.ColumnType_INT, Transform: transform.FromField("Embedded.stats.appsCount"), Description: "The number of applications the Group is assigned to."},
+			{Name: "group_push_mappings_count", Type: proto.ColumnType_INT, Transform: transform.FromField("Embedded.stats.groupPushMappingsCount"), Description: "The number of group push mappings of the Group."},
+			{Name: "source_app_id", Type: proto.ColumnType_STRING, Transform: transform.From(groupSourceAppId), Description: "Unique key for the application the Group is imported from, for groups of type APP_GROUP."},
+			{Name: "source_app_name", Type: proto.ColumnType_STRING, Hydrate: getGroupSourceApp, Transform: transform.FromField("Label"), Description: "The label of the application the Group is imported from, for groups of type APP_GROUP."},
+			{Name: "source_app_type", Type: proto.ColumnType_STRING, Hydrate: getGroupSourceApp, Transform: transform.FromField("Name"), Description: "The type of the application the Group is imported from, e.g. active_directory or ldap_sun_one."},
+			{Name: "has_admin_privilege", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Embedded.stats.hasAdminPrivilege"), Description: "True if the Group has admin roles assigned."},
+			{Name: "logo_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "logo"), Description: "The URL of the logo of the Group."},
+			{Name: "users_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "users"), Description: "The API URL listing the members of the Group."},
+			{Name: "apps_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "apps"), Description: "The API URL listing the applications the Group is assigned to."},
 
 			// JSON Columns
 			{Name: "profile", Type: proto.ColumnType_JSON, Description: "The Group's Profile properties."},
 			{Name: "object_class", Type: proto.ColumnType_JSON, Description: "Determines the Group's profile."},
-			{Name: "group_members", Type: proto.ColumnType_JSON, Hydrate: listGroupMembers, Transform: transform.From(transformGroupMembers), Description: "List of all users that are a member of this Group."},
+			{Name: "source_app", Type: proto.ColumnType_JSON, Transform: transform.FromField("Embedded.app"), Description: "The application the Group is imported from, for groups of type APP_GROUP."},
+			{Name: "group_members", Type: proto.ColumnType_JSON, Hydrate: listGroupMembers, Transform: transform.FromValue(), Description: "List of all users that are a member of this Group."},
+			{Name: "roles", Type: proto.ColumnType_JSON, Hydrate: listGroupAssignedRoles, Transform: transform.FromValue(), Description: "List of admin roles assigned to the Group."},
 
 			// Steampipe Columns
-			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Name"), Description: titleDescription},
-		}),
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Profile.name"), Description: titleDescription},
+		}, groupProfileColumns(ctx, td)...)),
 	}
 }
 
+// Embed the group statistics and, for APP_GROUP groups, the source application
+// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroups!in=query&path=expand&t=request
+const groupExpand = "stats,app"
+
+// GroupStructure is a group with its full profile. The SDK group profile only
+// has the name and description, and drops any custom profile attributes.
+type GroupStructure struct {
+	Embedded              interface{}            `json:"_embedded,omitempty"`
+	Links                 interface{}            `json:"_links,omitempty"`
+	Created               *time.Time             `json:"created,omitempty"`
+	Id                    string                 `json:"id,omitempty"`
+	LastMembershipUpdated *time.Time             `json:"lastMembershipUpdated,omitempty"`
+	LastUpdated           *time.Time             `json:"lastUpdated,omitempty"`
+	ObjectClass           []string               `json:"objectClass,omitempty"`
+	Profile               map[string]interface{} `json:"profile,omitempty"`
+	Type                  string                 `json:"type,omitempty"`
+}
+
 //// LIST FUNCTION
 
 func listOktaGroups(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	client, err := Connect(ctx, d)
-	if err != nil {
-		logger.Error("listOktaGroups", "connect_error", err)
-		return nil, err
-	}
 
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/groups/#list-groups
 	input := query.Params{
-		Limit: 10000,
-	}
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
+		Limit:  getPageSize(d, 10000),
+		Expand: groupExpand,
 	}
 
 	equalQuals := d.EqualsQuals
 	quals := d.Quals
 
-	var queryFilter string
-	filter := buildQueryFilter(equalQuals, []string{"id", "type", "last_updated", "last_membership_updated"})
+	var queryFilter, querySearch string
+	filter := buildGroupQueryFilter(equalQuals)
 
-	// TODO - optimize or move it to a utility function
 	// https://developer.okta.com/docs/reference/api-overview/#operators
-	if quals["last_updated"] != nil {
-		for _, q := range quals["last_updated"].Quals {
-			timeString := q.Value.GetTimestampValue().AsTime().Format(filterTimeFormat)
-			filter = append(filter, fmt.Sprintf("%s %s \"%s\"", "lastUpdated", operatorsMap[q.Operator], timeString))
+	for _, column := range []string{"last_updated", "last_membership_updated"} {
+		if quals[column] != nil {
+			for _, q := range quals[column].Quals {
+				timeString := q.Value.GetTimestampValue().AsTime().Format(filterTimeFormat)
+				filter = append(filter, fmt.Sprintf("%s %s \"%s\"", strcase.ToCamel(column), operatorsMap[q.Operator], timeString))
+			}
 		}
 	}
 
@@ -109,46 +142,55 @@ func listOktaGroups(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateD
 		queryFilter = equalQuals["filter"].GetStringValue()
 	}
 
-	if queryFilter != "" {
+	if equalQuals["search"] != nil {
+		querySearch = equalQuals["search"].GetStringValue()
+	}
+
+	// The filter parameter can't match group names, so a name is looked up
+	// with a search expression, which also takes the other conditions. Names
+	// aren't unique, e.g. those of groups imported from several apps, so the
+	// groups are listed rather than got.
+	name := d.EqualsQualString("name")
+
+	// Pages are only bounded by the limit of the query when no groups are
+	// skipped below, and not when listing the parents of okta_group_owner
+	// rows, as the limit is theirs
+	if name == "" && d.Table.Name == "okta_group" {
+		input.Limit = getListPageSize(d, 10000)
+	}
+
+	// Expressions given in the filter or search columns are passed verbatim
+	// and take precedence over the ones built from the other quals
+	if queryFilter != "" || querySearch != "" {
 		input.Filter = queryFilter
+		input.Search = querySearch
+	} else if name != "" {
+		input.Search = strings.Join(append(filter, fmt.Sprintf("profile.name eq \"%s\"", strings.ReplaceAll(name, `"`, `\"`))), " and ")
 	} else if len(filter) > 0 {
 		input.Filter = strings.Join(filter, " and ")
 	}
 
-	groups, resp, err := client.Group.ListGroups(ctx, &input)
+	groups, resp, err := listOktaGroupsPage(ctx, d, "/api/v1/groups"+input.String())
 	if err != nil {
 		logger.Error("listOktaGroups", "list_groups_error", err)
 		return nil, err
 	}
 
-	for _, group := range groups {
-		d.StreamListItem(ctx, group)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextGroupSet []*okta.Group
-		resp, err = resp.Next(ctx, &nextGroupSet)
-		if err != nil {
-			logger.Error("listOktaGroups", "list_groups_paging_error", err)
-			return nil, err
-		}
-		for _, group := range nextGroupSet {
-			d.StreamListItem(ctx, group)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+	// Only the groups named exactly as given are kept, as the search ignores case
+	err = streamPages(ctx, d, groups, urlPages(resp, func(url string) ([]*GroupStructure, *okta.Response, error) {
+		return listOktaGroupsPage(ctx, d, url)
+	}), func(group *GroupStructure) interface{} {
+		if name != "" && group.Profile["name"] != name {
+			return nil
 		}
+		return group
+	})
+	if err != nil {
+		logger.Error("listOktaGroups", "list_groups_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTIONS
@@ -159,7 +201,7 @@ func getOktaGroup(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDat
 
 	var groupId string
 	if h.Item != nil {
-		groupId = h.Item.(*okta.Group).Id
+		groupId = h.Item.(*GroupStructure).Id
 	} else {
 		groupId = d.EqualsQuals["id"].GetStringValue()
 	}
@@ -168,19 +210,35 @@ func getOktaGroup(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateDat
 		return nil, nil
 	}
 
+	release, err := acquireConnectionSemaphore(ctx, d, "get_group", getConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	client, err := Connect(ctx, d)
 	if err != nil {
 		logger.Error("getOktaGroup", "connect_error", err)
 		return nil, err
 	}
 
-	group, _, err := client.Group.GetGroup(ctx, groupId)
+	// The get group API doesn't support expand, so list the group by ID to
+	// include the group stats and source application
+	input := query.Params{
+		Filter: fmt.Sprintf("id eq \"%s\"", groupId),
+		Expand: groupExpand,
+	}
+	groups, _, err := listGroupsWithProfile(ctx, *client, &input)
 	if err != nil {
 		logger.Error("getOktaGroup", "get_group_error", err)
 		return nil, err
 	}
 
-	return group, nil
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	return groups[0], nil
 }
 
 func listGroupMembers(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -189,7 +247,7 @@ func listGroupMembers(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 
 	var groupId string
 	if h.Item != nil {
-		groupId = h.Item.(*okta.Group).Id
+		groupId = h.Item.(*GroupStructure).Id
 	} else {
 		groupId = d.EqualsQuals["id"].GetStringValue()
 	}
@@ -200,40 +258,261 @@ func listGroupMembers(ctx context.Context, d *plugin.QueryData, h *plugin.Hydrat
 		return nil, err
 	}
 
-	groupMembers, resp, err := client.Group.ListGroupUsers(ctx, groupId, &query.Params{})
+	// The API returns 1000 members per page by default and up to 10000
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroupUsers
+	users, resp, err := client.Group.ListGroupUsers(ctx, groupId, &query.Params{Limit: getPageSize(d, 10000)})
 	if err != nil {
 		logger.Error("listGroupMembers", "list_group_users_error", err)
 		return nil, err
 	}
 
-	// paging
-	for resp.HasNextPage() {
-		var nextgroupMembersSet []*okta.User
-		resp, err = resp.Next(ctx, &groupMembers)
-		if err != nil {
-			logger.Error("listOktaGroups", "list_group_users_paging_error", err)
-			return nil, err
-		}
-		groupMembers = append(groupMembers, nextgroupMembersSet...)
+	// Only the fields of the group_members column are kept from each page, so
+	// the full users of large groups aren't held in memory
+	groupMembers := make([]map[string]string, 0, len(users))
+	err = forEachPage(ctx, users, v2Pages[*okta.User](ctx, resp), func(page []*okta.User) bool {
+		groupMembers = appendGroupMembers(groupMembers, page)
+		return true
+	})
+	if err != nil {
+		logger.Error("listGroupMembers", "list_group_users_paging_error", err)
+		return nil, err
 	}
 
 	return groupMembers, nil
 }
 
+func listGroupAssignedRoles(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	group := h.Item.(*GroupStructure)
+
+	// Skip the lookup for groups that the embedded stats show have no admin roles
+	if embedded, ok := group.Embedded.(map[string]interface{}); ok {
+		if stats, ok := embedded["stats"].(map[string]interface{}); ok {
+			if hasAdminPrivilege, ok := stats["hasAdminPrivilege"].(bool); ok && !hasAdminPrivilege {
+				return []*okta.Role{}, nil
+			}
+		}
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("listGroupAssignedRoles", "connect_error", err)
+		return nil, err
+	}
+
+	roles, resp, err := client.Group.ListGroupAssignedRoles(ctx, group.Id, &query.Params{})
+	if err != nil {
+		logger.Error("listGroupAssignedRoles", "list_group_assigned_roles_error", err)
+		return nil, err
+	}
+
+	roles, err = collectPages(ctx, roles, v2Pages[*okta.Role](ctx, resp))
+	if err != nil {
+		logger.Error("listGroupAssignedRoles", "list_group_assigned_roles_paging_error", err)
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// GroupSourceApp is the application an APP_GROUP group is imported from
+type GroupSourceApp struct {
+	Id    string
+	Label string
+	Name  string
+}
+
+func getGroupSourceApp(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	group := h.Item.(*GroupStructure)
+	if group.Type != "APP_GROUP" {
+		return nil, nil
+	}
+
+	// The source application is embedded when listing with expand=app
+	if embedded, ok := group.Embedded.(map[string]interface{}); ok {
+		if app, ok := embedded["app"].(map[string]interface{}); ok {
+			id, _ := app["id"].(string)
+			label, _ := app["label"].(string)
+			name, _ := app["name"].(string)
+			if id != "" {
+				return &GroupSourceApp{Id: id, Label: label, Name: name}, nil
+			}
+		}
+	}
+
+	appId := getGroupSourceAppId(group)
+	if appId == "" {
+		return nil, nil
+	}
+
+	sourceApp, err := getOktaGroupSourceApp(ctx, d, &plugin.HydrateData{Item: appId})
+	if err != nil {
+		plugin.Logger(ctx).Error("getGroupSourceApp", "get_application_error", err)
+		return nil, err
+	}
+
+	return sourceApp, nil
+}
+
+// Groups imported from the same application share the lookup
+var getOktaGroupSourceAppMemoized = plugin.HydrateFunc(getOktaGroupSourceAppUncached).Memoize(memoize.WithCacheKeyFunction(getOktaGroupSourceAppCacheKey))
+
+func getOktaGroupSourceApp(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaGroupSourceAppMemoized(ctx, d, h)
+}
+
+func getOktaGroupSourceAppCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("getOktaGroupSourceApp-%s", h.Item.(string))
+	return key, nil
+}
+
+func getOktaGroupSourceAppUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	appId := h.Item.(string)
+	app, _, err := client.Application.GetApplication(ctx, appId, okta.NewApplication(), &query.Params{})
+	if err != nil {
+		// The application may have been deleted since the group was imported
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	application, ok := app.(*okta.Application)
+	if !ok {
+		return nil, nil
+	}
+
+	return &GroupSourceApp{Id: application.Id, Label: application.Label, Name: application.Name}, nil
+}
+
 //// TRANSFORM FUNCTION
 
-func transformGroupMembers(ctx context.Context, d *transform.TransformData) (interface{}, error) {
-	users := d.HydrateItem.([]*okta.User)
-	var usersData = []map[string]string{}
+func groupSourceAppId(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	appId := getGroupSourceAppId(d.HydrateItem.(*GroupStructure))
+	if appId == "" {
+		return nil, nil
+	}
+	return appId, nil
+}
 
+func groupProfileAttribute(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	group := d.HydrateItem.(*GroupStructure)
+	return group.Profile[d.Param.(string)], nil
+}
+
+func appendGroupMembers(groupMembers []map[string]string, users []*okta.User) []map[string]string {
 	for _, user := range users {
-		userProfile := *user.Profile
-		usersData = append(usersData, map[string]string{
-			"id":    user.Id,
-			"email": userProfile["email"].(string),
-			"login": userProfile["login"].(string),
-		})
+		member := map[string]string{"id": user.Id}
+		if user.Profile != nil {
+			member["email"], _ = (*user.Profile)["email"].(string)
+			member["login"], _ = (*user.Profile)["login"].(string)
+		}
+		groupMembers = append(groupMembers, member)
+	}
+
+	return groupMembers
+}
+
+//// UTILITY FUNCTION
+
+// groupProfileColumns returns a column for each custom attribute in the org's
+// default group schema, e.g. profile_cost_center for costCenter.
+func groupProfileColumns(ctx context.Context, td *plugin.TableMapData) []*plugin.Column {
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Schema/#tag/Schema/operation/getGroupSchema
+	return profileSchemaColumns(ctx, td, "/api/v1/meta/schemas/group/default", false, nil, groupProfileAttribute)
+}
+
+func listGroupsWithProfile(ctx context.Context, client okta.Client, qp *query.Params) ([]*GroupStructure, *okta.Response, error) {
+	url := "/api/v1/groups"
+	if qp != nil {
+		url = url + qp.String()
+	}
+
+	var groups []*GroupStructure
+
+	resp, err := listOktaPage(ctx, &client, url, &groups)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return groups, resp, nil
+}
+
+var listOktaGroupsPageMemoized = plugin.HydrateFunc(listOktaGroupsPageUncached).Memoize(memoize.WithCacheKeyFunction(getListPageCacheKey), memoize.WithTtl(listPageCacheTtl))
+
+// listOktaGroupsPage returns the page of groups at the URL, shared by the
+// scans of the query listing the same groups
+func listOktaGroupsPage(ctx context.Context, d *plugin.QueryData, url string) ([]*GroupStructure, *okta.Response, error) {
+	page, err := getListPage(ctx, d, url, listOktaGroupsPageMemoized, listOktaGroupsPageUncached)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page.items.([]*GroupStructure), page.resp, nil
+}
+
+func listOktaGroupsPageUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*GroupStructure
+
+	resp, err := listOktaPage(ctx, client, h.Item.(string), &groups)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listPage{items: groups, resp: resp}, nil
+}
+
+// The source link of an APP_GROUP group points to the application it is
+// imported from, e.g. https://{domain}/api/v1/apps/{appId}
+func getGroupSourceAppId(group *GroupStructure) string {
+	if group.Type != "APP_GROUP" {
+		return ""
+	}
+	links, ok := group.Links.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	source, ok := links["source"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	href, _ := source["href"].(string)
+	if href == "" {
+		return ""
+	}
+	return path.Base(href)
+}
+
+func buildGroupQueryFilter(equalQuals plugin.KeyColumnEqualsQualMap) []string {
+	filters := []string{}
+
+	for _, qual := range []string{"id", "type"} {
+		if equalQuals[qual] == nil {
+			continue
+		}
+		filterColumn := strcase.ToCamel(qual)
+		// Lists such as type in ('OKTA_GROUP', 'APP_GROUP') are ORed together
+		if listValue := equalQuals[qual].GetListValue(); listValue != nil {
+			var values []string
+			for _, value := range getListValues(listValue) {
+				values = append(values, fmt.Sprintf("%s eq \"%s\"", filterColumn, *value))
+			}
+			if len(values) > 0 {
+				filters = append(filters, "("+strings.Join(values, " or ")+")")
+			}
+			continue
+		}
+		filters = append(filters, fmt.Sprintf("%s eq \"%s\"", filterColumn, equalQuals[qual].GetStringValue()))
 	}
 
-	return usersData, nil
+	return filters
 }
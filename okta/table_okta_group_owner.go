@@ -4,7 +4,6 @@ import (
 	"context"
 	"time"
 
-	"github.com/okta/okta-sdk-golang/v2/okta"
 	oktav4 "github.com/okta/okta-sdk-golang/v4/okta"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -27,7 +26,7 @@ func tableOktaGroupOwner() *plugin.Table {
 			{Name: "group_id", Type: proto.ColumnType_STRING, Description: "Unique key for Group."},
 			{Name: "display_name", Type: proto.ColumnType_STRING, Description: "The display name of the group owner."},
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "The ID of the group owner."},
-			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Transform: transform.FromField("LastUpdated").Transform(transform.NullIfZeroValue).Transform(convertLastUpdatedTimestamp), Description: "Timestamp when the group owner was last updated."},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Transform: transform.FromField("LastUpdated"), Description: "Timestamp when the group owner was last updated."},
 			{Name: "origin_id", Type: proto.ColumnType_STRING, Description: "The ID of the app instance if the originType is APPLICATION. This value is NULL if originType is OKTA_DIRECTORY."},
 			{Name: "origin_type", Type: proto.ColumnType_STRING, Description: "The source where group ownership is managed."},
 			{Name: "type", Type: proto.ColumnType_STRING, Description: "The entity type of the owner."},
@@ -52,6 +51,12 @@ type GroupOwner struct {
 	Resolved             *bool
 	Type                 *string
 	AdditionalProperties map[string]interface{}
+
+	raw oktav4.GroupOwner
+}
+
+func (o GroupOwner) RawObject() interface{} {
+	return o.raw
 }
 
 //// LIST FUNCTION
@@ -61,7 +66,7 @@ func listOktaGroupOwners(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 
 	var groupId string
 	if h.Item != nil {
-		groupId = h.Item.(*okta.Group).Id
+		groupId = h.Item.(*GroupStructure).Id
 	} else {
 		groupId = d.EqualsQuals["group_id"].GetStringValue()
 	}
@@ -77,7 +82,9 @@ func listOktaGroupOwners(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, err
 	}
 
-	groupOwnerReq := client.GroupAPI.ListGroupOwners(ctx, groupId)
+	// The API returns up to 1000 owners per page
+	maxLimit := getListPageSize(d, 1000)
+	groupOwnerReq := client.GroupAPI.ListGroupOwners(ctx, groupId).Limit(int32(maxLimit))
 
 	owners, resp, err := groupOwnerReq.Execute()
 	if err != nil {
@@ -85,8 +92,8 @@ func listOktaGroupOwners(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 		return nil, err
 	}
 
-	for _, owner := range owners {
-		d.StreamListItem(ctx, GroupOwner{
+	err = streamPages(ctx, d, owners, v4Pages[oktav4.GroupOwner](resp), func(owner oktav4.GroupOwner) interface{} {
+		return GroupOwner{
 			GroupId:              &groupId,
 			DisplayName:          owner.DisplayName,
 			Id:                   owner.Id,
@@ -96,59 +103,13 @@ func listOktaGroupOwners(ctx context.Context, d *plugin.QueryData, h *plugin.Hyd
 			Resolved:             owner.Resolved,
 			Type:                 owner.Type,
 			AdditionalProperties: owner.AdditionalProperties,
-		})
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextGroupOwners []oktav4.GroupOwner
-		resp, err = resp.Next(&nextGroupOwners)
-		if err != nil {
-			logger.Error("okta_group_owner.listGroupOwners", "api_paging_error", err)
-			return nil, err
-		}
-		for _, owner := range nextGroupOwners {
-			d.StreamListItem(ctx, GroupOwner{
-				GroupId:              &groupId,
-				DisplayName:          owner.DisplayName,
-				Id:                   owner.Id,
-				LastUpdated:          owner.LastUpdated,
-				OriginId:             owner.OriginId,
-				OriginType:           owner.OriginType,
-				Resolved:             owner.Resolved,
-				Type:                 owner.Type,
-				AdditionalProperties: owner.AdditionalProperties,
-			})
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+			raw:                  owner,
 		}
+	})
+	if err != nil {
+		logger.Error("okta_group_owner.listGroupOwners", "api_paging_error", err)
+		return nil, err
 	}
 
 	return nil, nil
 }
-
-//// TRANSFORM FUNCTION
-
-// Convert "2023-09-26 12:18:57.0" time format to "2006-01-02T15:04:05Z07:00"
-func convertLastUpdatedTimestamp(ctx context.Context, d *transform.TransformData) (interface{}, error) {
-	value := d.Value
-	if value == nil {
-		return nil, nil
-	}
-	lastUpdatedTime := value.(*time.Time)
-
-	formattedTime := lastUpdatedTime.UTC()
-
-	// Parse the input time string
-	rfc3339Time := formattedTime.Format(time.RFC3339)
-
-	return rfc3339Time, nil
-}
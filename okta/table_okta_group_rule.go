@@ -15,13 +15,13 @@ func tableOktaGroupRule() *plugin.Table {
 		Name:        "okta_group_rule",
 		Description: "Retrieve group rules for Okta. Group rules define conditions and actions for automating group membership.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaGroupRule,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaGroupRule,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
-			Hydrate: listOktaGroupRules,
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      listOktaGroupRules,
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		Columns: commonColumns( []*plugin.Column{
 			// Basic columns
@@ -54,16 +54,7 @@ func listOktaGroupRules(ctx context.Context, d *plugin.QueryData, _ *plugin.Hydr
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/groups/#list-group-rules
 	input := query.Params{
-		Limit: 200,
-	}
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
+		Limit: getListPageSize(d, 200),
 	}
 
 	// Fetch group rules
@@ -73,34 +64,13 @@ func listOktaGroupRules(ctx context.Context, d *plugin.QueryData, _ *plugin.Hydr
 		return nil, err
 	}
 
-	for _, rule := range groupRules {
-		d.StreamListItem(ctx, rule)
-
-		// Exit if the context is canceled
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextGroupRuleSet []*okta.GroupRule
-		resp, err = resp.Next(ctx, &nextGroupRuleSet)
-		if err != nil {
-			logger.Error("okta_group_rule.listOktaGroupRules", "api_paging_error", err)
-			return nil, err
-		}
-		for _, group_rule := range nextGroupRuleSet {
-			d.StreamListItem(ctx, group_rule)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
+	err = streamPages(ctx, d, groupRules, v2Pages[*okta.GroupRule](ctx, resp), nil)
+	if err != nil {
+		logger.Error("okta_group_rule.listOktaGroupRules", "api_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTION
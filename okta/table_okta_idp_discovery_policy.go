@@ -3,8 +3,7 @@ package okta
 import (
 	"context"
 
-	"github.com/okta/okta-sdk-golang/v2/okta"
-	"github.com/okta/okta-sdk-golang/v2/okta/query"
+	oktaV4 "github.com/okta/okta-sdk-golang/v4/okta"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
@@ -17,8 +16,13 @@ func tableOktaIdpDiscoveryPolicy() *plugin.Table {
 		Name:        "okta_idp_discovery_policy",
 		Description: "The IdP Discovery Policy determines where to route Users when they are attempting to sign in to your org. Users can be routed to a variety of Identity Providers (SAML2, IWA, AgentlessDSSO, X509, FACEBOOK, GOOGLE, LINKEDIN, MICROSOFT, OIDC) based on multiple conditions.",
 		List: &plugin.ListConfig{
-			Hydrate: listOktaIdpDiscoveryPolicies,
+			Hydrate: listPolicies,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "name", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
 		},
+		HydrateConfig: append(policyHydrateConfig(), plugin.HydrateConfig{Func: getOktaIdpDiscoveryRoutingRules, MaxConcurrency: 10}),
 		Columns: commonColumns([]*plugin.Column{
 			// Top Columns
 			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the Policy."},
@@ -34,7 +38,9 @@ func tableOktaIdpDiscoveryPolicy() *plugin.Table {
 
 			// JSON Columns
 			{Name: "conditions", Type: proto.ColumnType_JSON, Description: "Conditions for Policy."},
+			{Name: "applies_to", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAppliesTo, Transform: transform.FromValue(), Description: "The groups and users included in or excluded from the Policy, with their IDs resolved to names."},
 			{Name: "rules", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRules, Transform: transform.FromValue(), Description: "Each Policy may contain one or more Rules. Rules, like Policies, contain conditions that must be satisfied for the Rule to be applied."},
+			{Name: "routing_rules", Type: proto.ColumnType_JSON, Hydrate: getOktaIdpDiscoveryRoutingRules, Transform: transform.FromValue(), Description: "The routing rules of the Policy, flattened to the apps, user identifier patterns and network conditions each rule matches and the identity providers it routes to."},
 			{Name: "resource_mapping", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAssociatedResources, Transform: transform.FromValue(), Description: "The resources that are mapped to the Policy."},
 
 			// Steampipe Columns
@@ -43,48 +49,106 @@ func tableOktaIdpDiscoveryPolicy() *plugin.Table {
 	}
 }
 
-func listOktaIdpDiscoveryPolicies(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
-	logger := plugin.Logger(ctx)
-	client, err := Connect(ctx, d)
+type IdpRoutingRule struct {
+	RuleId                  string               `json:"rule_id"`
+	RuleName                string               `json:"rule_name"`
+	Priority                *int32               `json:"priority"`
+	Status                  string               `json:"status"`
+	AppInclude              []IdpRoutingApp      `json:"app_include"`
+	AppExclude              []IdpRoutingApp      `json:"app_exclude"`
+	UserIdentifierType      *string              `json:"user_identifier_type"`
+	UserIdentifierAttribute *string              `json:"user_identifier_attribute"`
+	UserIdentifierPatterns  []IdpRoutingPattern  `json:"user_identifier_patterns"`
+	NetworkConnection       *string              `json:"network_connection"`
+	NetworkInclude          []string             `json:"network_include"`
+	NetworkExclude          []string             `json:"network_exclude"`
+	IdpSelectionType        *string              `json:"idp_selection_type"`
+	Providers               []IdpRoutingProvider `json:"providers"`
+}
 
-	input := &query.Params{}
+type IdpRoutingApp struct {
+	Id   *string `json:"id"`
+	Name *string `json:"name"`
+	Type *string `json:"type"`
+}
 
-	if err != nil {
-		logger.Error("listOktaIdpDiscoveryPolicies", "connect_error", err)
-		return nil, err
-	}
-	if d.Table.Name == "okta_idp_discovery_policy" {
-		input.Type = "IDP_DISCOVERY"
+type IdpRoutingPattern struct {
+	MatchType *string `json:"match_type"`
+	Value     *string `json:"value"`
+}
+
+type IdpRoutingProvider struct {
+	Id   *string `json:"id"`
+	Name *string `json:"name"`
+	Type *string `json:"type"`
+}
+
+//// HYDRATE FUNCTIONS
+
+func getOktaIdpDiscoveryRoutingRules(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	if getPolicyId(h.Item) == "" {
+		return nil, nil
 	}
-	policies, resp, err := client.Policy.ListPolicies(ctx, input)
+
+	rules, err := listOktaPolicyRules(ctx, d, h)
 	if err != nil {
-		logger.Error("listOktaIdpDiscoveryPolicies", "list_policies_error", err)
+		logger.Error("okta_idp_discovery_policy.getOktaIdpDiscoveryRoutingRules", "list_policy_rules_error", err)
 		return nil, err
 	}
-	for _, policy := range policies {
-		d.StreamListItem(ctx, policy)
 
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
+	routingRules := []IdpRoutingRule{}
+	for _, rule := range rules {
+		r, ok := rule.GetActualInstance().(*oktaV4.IdpDiscoveryPolicyRule)
+		if !ok {
+			continue
 		}
-	}
-	// paging
-	for resp.HasNextPage() {
-		var nextPolicySet []*okta.AuthorizationServerPolicy
-		resp, err = resp.Next(ctx, &nextPolicySet)
-		if err != nil {
-			logger.Error("listOktaIdpDiscoveryPolicies", "list_policies_paging_error", err)
-			return nil, err
+
+		routingRule := IdpRoutingRule{
+			RuleId:                 r.GetId(),
+			RuleName:               r.GetName(),
+			Priority:               r.Priority,
+			Status:                 r.GetStatus(),
+			AppInclude:             []IdpRoutingApp{},
+			AppExclude:             []IdpRoutingApp{},
+			UserIdentifierPatterns: []IdpRoutingPattern{},
+			NetworkInclude:         []string{},
+			NetworkExclude:         []string{},
+			Providers:              []IdpRoutingProvider{},
 		}
-		for _, policy := range nextPolicySet {
-			d.StreamListItem(ctx, policy)
 
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
+		if conditions := r.Conditions; conditions != nil {
+			if conditions.App != nil {
+				for _, app := range conditions.App.Include {
+					routingRule.AppInclude = append(routingRule.AppInclude, IdpRoutingApp{Id: app.Id, Name: app.Name, Type: app.Type})
+				}
+				for _, app := range conditions.App.Exclude {
+					routingRule.AppExclude = append(routingRule.AppExclude, IdpRoutingApp{Id: app.Id, Name: app.Name, Type: app.Type})
+				}
+			}
+			if userIdentifier := conditions.UserIdentifier; userIdentifier != nil {
+				routingRule.UserIdentifierType = userIdentifier.Type
+				routingRule.UserIdentifierAttribute = userIdentifier.Attribute
+				for _, pattern := range userIdentifier.Patterns {
+					routingRule.UserIdentifierPatterns = append(routingRule.UserIdentifierPatterns, IdpRoutingPattern{MatchType: pattern.MatchType, Value: pattern.Value})
+				}
+			}
+			if network := conditions.Network; network != nil {
+				routingRule.NetworkConnection = network.Connection
+				routingRule.NetworkInclude = append(routingRule.NetworkInclude, network.Include...)
+				routingRule.NetworkExclude = append(routingRule.NetworkExclude, network.Exclude...)
 			}
 		}
+
+		if r.Actions != nil && r.Actions.Idp != nil {
+			routingRule.IdpSelectionType = r.Actions.Idp.IdpSelectionType
+			for _, provider := range r.Actions.Idp.Providers {
+				routingRule.Providers = append(routingRule.Providers, IdpRoutingProvider{Id: provider.Id, Name: provider.Name, Type: provider.Type})
+			}
+		}
+
+		routingRules = append(routingRules, routingRule)
 	}
-	return nil, err
+
+	return routingRules, nil
 }
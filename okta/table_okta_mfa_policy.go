@@ -1,7 +1,12 @@
 package okta
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
 
 //// TABLE DEFINITION
@@ -12,7 +17,253 @@ func tableOktaMfaPolicy() *plugin.Table {
 		Description: "The Multifactor (MFA) Enrollment Policy controls which MFA methods are available for a User, as well as when a User may enroll in a particular Factor.",
 		List: &plugin.ListConfig{
 			Hydrate: listPolicies,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "name", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
 		},
-		Columns: commonColumns(listPoliciesWithSettingsColumns()),
+		HydrateConfig: append(policyHydrateConfig(), plugin.HydrateConfig{Func: getMfaPolicyRuleEnrollments, MaxConcurrency: 10}),
+		Columns:       commonColumns(append(listPoliciesWithSettingsColumns(), mfaPolicySettingsColumns()...)),
+	}
+}
+
+// Authenticator keys used by Identity Engine authenticator enrollment policies
+var mfaPolicyAuthenticatorKeys = []string{
+	"okta_verify",
+	"okta_password",
+	"okta_email",
+	"phone_number",
+	"security_question",
+	"google_otp",
+	"webauthn",
+	"yubikey_token",
+	"duo",
+	"rsa_token",
+	"symantec_vip",
+	"custom_otp",
+	"smart_card_idp",
+	"external_idp",
+}
+
+// Classic Engine factor keys mapped to the equivalent Identity Engine authenticator key
+var mfaPolicyFactorAuthenticators = map[string]string{
+	"okta_otp":      "okta_verify",
+	"okta_push":     "okta_verify",
+	"okta_password": "okta_password",
+	"okta_email":    "okta_email",
+	"okta_sms":      "phone_number",
+	"okta_call":     "phone_number",
+	"okta_question": "security_question",
+	"google_otp":    "google_otp",
+	"fido_webauthn": "webauthn",
+	"fido_u2f":      "webauthn",
+	"yubikey_token": "yubikey_token",
+	"duo":           "duo",
+	"rsa_token":     "rsa_token",
+	"symantec_vip":  "symantec_vip",
+	"external_idp":  "external_idp",
+}
+
+// Enrollment requirements ordered from least to most restrictive
+var mfaPolicyEnrollRank = map[string]int{
+	"NOT_ALLOWED": 1,
+	"OPTIONAL":    2,
+	"REQUIRED":    3,
+}
+
+func mfaPolicySettingsColumns() []*plugin.Column {
+	columns := []*plugin.Column{
+		// MFA Settings Columns
+		{Name: "settings_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("Settings.type"), Description: "The type of the enrollment policy settings: AUTHENTICATORS (Identity Engine) or FACTORS (Classic Engine)."},
+		{Name: "required_authenticators", Type: proto.ColumnType_JSON, Transform: transform.From(mfaPolicyRequiredAuthenticators), Description: "The keys of the authenticators users are required to enroll."},
+		{Name: "rule_enrollments", Type: proto.ColumnType_JSON, Hydrate: getMfaPolicyRuleEnrollments, Transform: transform.FromValue(), Description: "One entry per authenticator for each rule of the Policy, with the rule, when the rule prompts users to enroll, the authenticator enrollment requirement and the groups the Policy applies to."},
+	}
+	for _, key := range mfaPolicyAuthenticatorKeys {
+		columns = append(columns, &plugin.Column{
+			Name:        "authenticator_" + key,
+			Type:        proto.ColumnType_JSON,
+			Transform:   transform.From(mfaPolicyAuthenticators).Transform(mfaPolicyAuthenticatorSettings(key)),
+			Description: fmt.Sprintf("The enrollment settings of the %s authenticator, including whether enrollment is REQUIRED, OPTIONAL or NOT_ALLOWED.", key),
+		})
+	}
+	return columns
+}
+
+//// HYDRATE FUNCTIONS
+
+type MfaPolicyRuleEnrollment struct {
+	RuleName      string   `json:"rule_name"`
+	RuleStatus    string   `json:"rule_status"`
+	EnrollWhen    string   `json:"enroll_when"`
+	Authenticator string   `json:"authenticator"`
+	Enrollment    string   `json:"enrollment"`
+	IncludeGroups []string `json:"include_groups"`
+	ExcludeUsers  []string `json:"exclude_users"`
+}
+
+// getMfaPolicyRuleEnrollments flattens the rules of an enrollment policy into
+// one entry per rule and authenticator. The authenticator requirements and
+// groups are set on the policy, while each rule decides when users are
+// prompted to enroll and which users it excludes.
+func getMfaPolicyRuleEnrollments(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	policy, ok := h.Item.(*PolicyStructure)
+	if !ok || policy.Id == "" {
+		return nil, nil
+	}
+
+	rules, err := listOktaPolicyRules(ctx, d, h)
+	if err != nil {
+		logger.Error("getMfaPolicyRuleEnrollments", "list_policy_rules_error", err)
+		return nil, err
+	}
+
+	includeGroups := []string{}
+	if policy.Conditions != nil && policy.Conditions.People != nil && policy.Conditions.People.Groups != nil {
+		includeGroups = append(includeGroups, policy.Conditions.People.Groups.Include...)
+	}
+	authenticators := getMfaPolicyAuthenticators(policy)
+
+	enrollments := []MfaPolicyRuleEnrollment{}
+	for _, rule := range rules {
+		var r struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			Conditions struct {
+				People struct {
+					Users struct {
+						Exclude []string `json:"exclude"`
+					} `json:"users"`
+				} `json:"people"`
+			} `json:"conditions"`
+			Actions struct {
+				Enroll struct {
+					Self string `json:"self"`
+				} `json:"enroll"`
+			} `json:"actions"`
+		}
+		if err := decodeUnionInstance(&rule, &r); err != nil {
+			logger.Error("getMfaPolicyRuleEnrollments", "decode_rule_error", err)
+			return nil, err
+		}
+
+		excludeUsers := r.Conditions.People.Users.Exclude
+		if excludeUsers == nil {
+			excludeUsers = []string{}
+		}
+		for _, key := range mfaPolicyAuthenticatorKeys {
+			authenticator, ok := authenticators[key]
+			if !ok {
+				continue
+			}
+			enrollments = append(enrollments, MfaPolicyRuleEnrollment{
+				RuleName:      r.Name,
+				RuleStatus:    r.Status,
+				EnrollWhen:    r.Actions.Enroll.Self,
+				Authenticator: key,
+				Enrollment:    getMfaPolicyEnrollment(authenticator),
+				IncludeGroups: includeGroups,
+				ExcludeUsers:  excludeUsers,
+			})
+		}
+	}
+
+	return enrollments, nil
+}
+
+//// TRANSFORM FUNCTIONS
+
+// mfaPolicyAuthenticators returns the enrollment settings of each authenticator
+// keyed by the Identity Engine authenticator key
+func mfaPolicyAuthenticators(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	policy, ok := d.HydrateItem.(*PolicyStructure)
+	if !ok {
+		return nil, nil
+	}
+	return getMfaPolicyAuthenticators(policy), nil
+}
+
+func mfaPolicyAuthenticatorSettings(key string) transform.TransformFunc {
+	return func(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+		authenticators, ok := d.Value.(map[string]map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		if authenticator, ok := authenticators[key]; ok {
+			return authenticator, nil
+		}
+		return nil, nil
+	}
+}
+
+func mfaPolicyRequiredAuthenticators(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	policy, ok := d.HydrateItem.(*PolicyStructure)
+	if !ok {
+		return nil, nil
+	}
+
+	required := []string{}
+	authenticators := getMfaPolicyAuthenticators(policy)
+	for _, key := range mfaPolicyAuthenticatorKeys {
+		if authenticator, ok := authenticators[key]; ok && getMfaPolicyEnrollment(authenticator) == "REQUIRED" {
+			required = append(required, key)
+		}
+	}
+	return required, nil
+}
+
+//// UTILITY FUNCTION
+
+// getMfaPolicyAuthenticators normalizes the Identity Engine authenticators list
+// and the Classic Engine factors map into a single map keyed by authenticator.
+// Where several factors map to the same authenticator, the most restrictive
+// enrollment wins.
+func getMfaPolicyAuthenticators(policy *PolicyStructure) map[string]map[string]interface{} {
+	authenticators := map[string]map[string]interface{}{}
+	settings, ok := policy.Settings.(map[string]interface{})
+	if !ok {
+		return authenticators
+	}
+
+	add := func(key string, authenticator map[string]interface{}) {
+		if existing, ok := authenticators[key]; ok && mfaPolicyEnrollRank[getMfaPolicyEnrollment(existing)] >= mfaPolicyEnrollRank[getMfaPolicyEnrollment(authenticator)] {
+			return
+		}
+		authenticators[key] = authenticator
+	}
+
+	if list, ok := settings["authenticators"].([]interface{}); ok {
+		for _, item := range list {
+			if authenticator, ok := item.(map[string]interface{}); ok {
+				if key, ok := authenticator["key"].(string); ok {
+					add(key, authenticator)
+				}
+			}
+		}
+	}
+
+	if factors, ok := settings["factors"].(map[string]interface{}); ok {
+		for factorKey, item := range factors {
+			factor, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			key, ok := mfaPolicyFactorAuthenticators[factorKey]
+			if !ok {
+				key = factorKey
+			}
+			add(key, factor)
+		}
+	}
+
+	return authenticators
+}
+
+func getMfaPolicyEnrollment(authenticator map[string]interface{}) string {
+	if enroll, ok := authenticator["enroll"].(map[string]interface{}); ok {
+		if self, ok := enroll["self"].(string); ok {
+			return self
+		}
 	}
+	return ""
 }
@@ -59,14 +59,7 @@ func listOktaNetworkZones(ctx context.Context, d *plugin.QueryData, _ *plugin.Hy
 		return nil, err
 	}
 
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := int64(200)
-	if d.QueryContext.Limit != nil {
-		if *d.QueryContext.Limit < limit {
-			limit = *d.QueryContext.Limit
-		}
-	}
+	limit := getListPageSize(d, 200)
 
 	// Request
 	zoneReq := client.NetworkZoneAPI.ListNetworkZones(ctx)
@@ -77,43 +70,13 @@ func listOktaNetworkZones(ctx context.Context, d *plugin.QueryData, _ *plugin.Hy
 		return nil, err
 	}
 
-	for _, zone := range zones {
-		z := processNetworkZones(zone)
-		if z != nil {
-			d.StreamListItem(ctx, z)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextZoneSet []okta.ListNetworkZones200ResponseInner
-
-		resp, err = resp.Next(&nextZoneSet)
-		if err != nil {
-			logger.Error("listOktaNetworkZones", "list_network_zones_paging_error", err)
-			return nil, err
-		}
-
-		for _, zone := range nextZoneSet {
-			z := processNetworkZones(zone)
-			if z != nil {
-				d.StreamListItem(ctx, z)
-
-				// Context can be cancelled due to manual cancellation or the limit has been hit
-				if d.RowsRemaining(ctx) == 0 {
-					return nil, nil
-				}
-			}
-		}
-
+	err = streamPages(ctx, d, zones, v5Pages[okta.ListNetworkZones200ResponseInner](resp), processNetworkZones)
+	if err != nil {
+		logger.Error("listOktaNetworkZones", "list_network_zones_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTION
@@ -19,11 +19,105 @@ func tableOktaPasswordPolicy() *plugin.Table {
 		Description: "The Password Policy determines the requirements for a user's password length and complexity, as well as the frequency with which a password must be changed. This Policy also governs the recovery operations that may be performed by the User, including change password, reset (forgot) password, and self-service password unlock.",
 		List: &plugin.ListConfig{
 			Hydrate: listPolicies,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "name", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
 		},
-		Columns: commonColumns(listPoliciesWithSettingsColumns()),
+		HydrateConfig: append(policyHydrateConfig(), plugin.HydrateConfig{Func: getPasswordPolicySelfServiceAccess, MaxConcurrency: 10}),
+		Columns:       commonColumns(append(listPoliciesWithSettingsColumns(), passwordPolicySettingsColumns()...)),
 	}
 }
 
+func passwordPolicySettingsColumns() []*plugin.Column {
+	return []*plugin.Column{
+		// Password Settings Columns
+		{Name: "min_length", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.complexity.minLength"), Description: "Minimum password length."},
+		{Name: "min_lowercase", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.complexity.minLowerCase"), Description: "Minimum number of lowercase characters required in a password."},
+		{Name: "min_uppercase", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.complexity.minUpperCase"), Description: "Minimum number of uppercase characters required in a password."},
+		{Name: "min_number", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.complexity.minNumber"), Description: "Minimum number of numeric characters required in a password."},
+		{Name: "min_symbol", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.complexity.minSymbol"), Description: "Minimum number of symbol characters required in a password."},
+		{Name: "exclude_username", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Settings.password.complexity.excludeUsername"), Description: "True if the password must not contain the user's username."},
+		{Name: "exclude_common_passwords", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Settings.password.complexity.dictionary.common.exclude"), Description: "True if the password is checked against a dictionary of common passwords."},
+		{Name: "max_age_days", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.age.maxAgeDays"), Description: "Number of days a password remains valid before it expires. 0 means the password never expires."},
+		{Name: "min_age_minutes", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.age.minAgeMinutes"), Description: "Minimum number of minutes required since the last password change before the password can be changed again."},
+		{Name: "expire_warn_days", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.age.expireWarnDays"), Description: "Number of days before password expiration that the user is warned."},
+		{Name: "history_count", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.age.historyCount"), Description: "Number of previous passwords that can't be reused."},
+		{Name: "lockout_max_attempts", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.lockout.maxAttempts"), Description: "Number of unsuccessful sign-in attempts allowed before the user is locked out. 0 means lockout is disabled."},
+		{Name: "lockout_auto_unlock_minutes", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.password.lockout.autoUnlockMinutes"), Description: "Number of minutes after which a locked out user is automatically unlocked. 0 means the user must be unlocked by an administrator."},
+
+		// Recovery Settings Columns
+		{Name: "recovery_email_enabled", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Settings.recovery.factors.okta_email.status").Transform(isActiveStatus), Description: "True if users can recover their account with an email."},
+		{Name: "recovery_email_token_lifetime_minutes", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.recovery.factors.okta_email.properties.recoveryToken.tokenLifetimeMinutes"), Description: "Number of minutes the recovery link or code sent by email remains valid."},
+		{Name: "recovery_sms_enabled", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Settings.recovery.factors.okta_sms.status").Transform(isActiveStatus), Description: "True if users can recover their account with an SMS."},
+		{Name: "recovery_call_enabled", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Settings.recovery.factors.okta_call.status").Transform(isActiveStatus), Description: "True if users can recover their account with a voice call."},
+		{Name: "recovery_question_enabled", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Settings.recovery.factors.recovery_question.status").Transform(isActiveStatus), Description: "True if users must answer their security question to recover their account."},
+		{Name: "recovery_question_min_length", Type: proto.ColumnType_INT, Transform: transform.FromField("Settings.recovery.factors.recovery_question.properties.complexity.minLength"), Description: "Minimum length of the answer to the security question."},
+		{Name: "self_service_password_reset_allowed", Type: proto.ColumnType_BOOL, Hydrate: getPasswordPolicySelfServiceAccess, Transform: transform.FromField("PasswordResetAllowed"), Description: "True if any active rule of the Policy lets users reset their forgotten password."},
+		{Name: "self_service_unlock_allowed", Type: proto.ColumnType_BOOL, Hydrate: getPasswordPolicySelfServiceAccess, Transform: transform.FromField("UnlockAllowed"), Description: "True if any active rule of the Policy lets users unlock their account."},
+	}
+}
+
+type PasswordPolicySelfServiceAccess struct {
+	PasswordResetAllowed bool
+	UnlockAllowed        bool
+}
+
+// getPasswordPolicySelfServiceAccess checks whether the active rules of a
+// password policy allow self-service password reset and account unlock
+func getPasswordPolicySelfServiceAccess(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	if getPolicyId(h.Item) == "" {
+		return nil, nil
+	}
+
+	rules, err := listOktaPolicyRules(ctx, d, h)
+	if err != nil {
+		logger.Error("getPasswordPolicySelfServiceAccess", "list_policy_rules_error", err)
+		return nil, err
+	}
+
+	access := &PasswordPolicySelfServiceAccess{}
+	for _, rule := range rules {
+		var r struct {
+			Status  string `json:"status"`
+			Actions struct {
+				SelfServicePasswordReset struct {
+					Access string `json:"access"`
+				} `json:"selfServicePasswordReset"`
+				SelfServiceUnlock struct {
+					Access string `json:"access"`
+				} `json:"selfServiceUnlock"`
+			} `json:"actions"`
+		}
+		if err := decodeUnionInstance(&rule, &r); err != nil {
+			logger.Error("getPasswordPolicySelfServiceAccess", "decode_rule_error", err)
+			return nil, err
+		}
+		if r.Status != "ACTIVE" {
+			continue
+		}
+		if r.Actions.SelfServicePasswordReset.Access == "ALLOW" {
+			access.PasswordResetAllowed = true
+		}
+		if r.Actions.SelfServiceUnlock.Access == "ALLOW" {
+			access.UnlockAllowed = true
+		}
+	}
+
+	return access, nil
+}
+
+//// TRANSFORM FUNCTION
+
+// isActiveStatus converts the ACTIVE or INACTIVE status of a setting to a bool
+func isActiveStatus(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	if d.Value == nil {
+		return nil, nil
+	}
+	return d.Value == "ACTIVE", nil
+}
+
 func listPoliciesWithSettingsColumns() []*plugin.Column {
 	return []*plugin.Column{
 		// Top Columns
@@ -40,6 +134,7 @@ func listPoliciesWithSettingsColumns() []*plugin.Column {
 
 		// JSON Columns
 		{Name: "conditions", Type: proto.ColumnType_JSON, Description: "Conditions for Policy."},
+		{Name: "applies_to", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAppliesTo, Transform: transform.FromValue(), Description: "The groups and users included in or excluded from the Policy, with their IDs resolved to names."},
 		{Name: "rules", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRules, Transform: transform.FromValue(), Description: "Each Policy may contain one or more Rules. Rules, like Policies, contain conditions that must be satisfied for the Rule to be applied."},
 		{Name: "settings", Type: proto.ColumnType_JSON, Description: "Settings of the password policy."},
 		{Name: "resource_mapping", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAssociatedResources, Transform: transform.FromValue(), Description: "The resources that are mapped to the Policy."},
@@ -49,57 +144,67 @@ func listPoliciesWithSettingsColumns() []*plugin.Column {
 	}
 }
 
+// Policy type listed by each policy table
+var policyTypesByTable = map[string]string{
+	"okta_authentication_policy":     "ACCESS_POLICY",
+	"okta_idp_discovery_policy":      "IDP_DISCOVERY",
+	"okta_mfa_policy":                "MFA_ENROLL",
+	"okta_password_policy":           "PASSWORD",
+	"okta_profile_enrollment_policy": "PROFILE_ENROLLMENT",
+	"okta_signon_policy":             "OKTA_SIGN_ON",
+}
+
 func listPolicies(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
 	client, err := Connect(ctx, d)
-
-	input := &query.Params{}
 	if err != nil {
-		logger.Error("listOktaPolicies", "connect_error", err)
+		logger.Error("listPolicies", "connect_error", err)
 		return nil, err
 	}
 
-	switch d.Table.Name {
-	case "okta_password_policy":
-		input.Type = "PASSWORD"
-	case "okta_mfa_policy":
-		input.Type = "MFA_ENROLL"
+	input := &query.Params{
+		Type: policyTypesByTable[d.Table.Name],
 	}
 
-	policies, resp, err := listPoliciesWithSettings(ctx, *client, input)
-	if err != nil {
-		logger.Error("listPolicies", "list_policies_with_settings_error", err)
-		return nil, err
+	status := d.EqualsQualString("status")
+	if status != "" {
+		input.Status = status
 	}
 
-	for _, policy := range policies {
-		d.StreamListItem(ctx, policy)
+	// The q parameter matches policies whose name contains the value, so the
+	// exact name is checked again below
+	name := d.EqualsQualString("name")
+	if name != "" {
+		input.Q = name
+	}
 
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
+	// Only request as many policies as the query needs when none are skipped
+	// below, otherwise a smaller page would only mean more requests
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Policy/#tag/Policy/operation/listPolicies
+	if d.QueryContext.Limit != nil && name == "" {
+		input.Limit = *d.QueryContext.Limit
 	}
 
-	// paging
-	for resp.HasNextPage() {
-		var nextPolicySet []*okta.Policy
-		resp, err = resp.Next(ctx, &nextPolicySet)
-		if err != nil {
-			logger.Error("listPolicies", "list_policies_with_settings_paging_error", err)
-			return nil, err
-		}
-		for _, policy := range nextPolicySet {
-			d.StreamListItem(ctx, policy)
+	policies, resp, err := listPoliciesWithSettings(ctx, *client, input)
+	if err != nil {
+		logger.Error("listPolicies", "list_policies_with_settings_error", err)
+		return nil, err
+	}
 
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+	// Every page is decoded into the same structure as the first page so the
+	// settings of policies beyond the first page are not dropped
+	err = streamPages(ctx, d, policies, v2Pages[*PolicyStructure](ctx, resp), func(policy *PolicyStructure) interface{} {
+		if name != "" && policy.Name != name {
+			return nil
 		}
+		return policy
+	})
+	if err != nil {
+		logger.Error("listPolicies", "list_policies_with_settings_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 // Generic policy returned by
@@ -0,0 +1,128 @@
+package okta
+
+import (
+	"context"
+	"path"
+
+	oktaV4 "github.com/okta/okta-sdk-golang/v4/okta"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaProfileEnrollmentPolicy() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_profile_enrollment_policy",
+		Description: "The Profile Enrollment Policy determines whether users can register themselves, which profile attributes are collected and whether the email address must be verified.",
+		List: &plugin.ListConfig{
+			Hydrate: listPolicies,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "name", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
+		},
+		HydrateConfig: append(policyHydrateConfig(), plugin.HydrateConfig{Func: getOktaProfileEnrollmentSettings, MaxConcurrency: 10}),
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the Policy."},
+			{Name: "id", Type: proto.ColumnType_STRING, Description: "Identifier of the Policy."},
+			{Name: "description", Type: proto.ColumnType_STRING, Description: "Description of the Policy."},
+			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the Policy was created."},
+
+			// Other Columns
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when the Policy was last modified."},
+			{Name: "priority", Type: proto.ColumnType_INT, Description: "Priority of the Policy."},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "Status of the Policy: ACTIVE or INACTIVE."},
+			{Name: "system", Type: proto.ColumnType_BOOL, Description: "This is set to true on system policies, which cannot be deleted."},
+			{Name: "self_service_registration_enabled", Type: proto.ColumnType_BOOL, Hydrate: getOktaProfileEnrollmentSettings, Transform: transform.FromField("SelfServiceRegistrationEnabled"), Description: "True if an active rule lets unknown users register themselves."},
+			{Name: "email_verification_required", Type: proto.ColumnType_BOOL, Hydrate: getOktaProfileEnrollmentSettings, Transform: transform.FromField("EmailVerificationRequired"), Description: "True if every active rule requires users to verify their email address before their account is activated."},
+
+			// JSON Columns
+			{Name: "conditions", Type: proto.ColumnType_JSON, Description: "Conditions for Policy."},
+			{Name: "applies_to", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAppliesTo, Transform: transform.FromValue(), Description: "The groups and users included in or excluded from the Policy, with their IDs resolved to names."},
+			{Name: "progressive_profiling_attributes", Type: proto.ColumnType_JSON, Hydrate: getOktaProfileEnrollmentSettings, Transform: transform.FromField("ProgressiveProfilingAttributes"), Description: "The profile attributes collected from existing users at sign in by active rules with progressive profiling enabled."},
+			{Name: "assigned_apps", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAssociatedResources, Transform: transform.FromValue().Transform(policyMappingApps), Description: "The IDs and names of the applications the Policy is assigned to."},
+			{Name: "rules", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRules, Transform: transform.FromValue(), Description: "Each Policy may contain one or more Rules. Rules, like Policies, contain conditions that must be satisfied for the Rule to be applied."},
+			{Name: "resource_mapping", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAssociatedResources, Transform: transform.FromValue(), Description: "The resources that are mapped to the Policy."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Name"), Description: titleDescription},
+		}),
+	}
+}
+
+type ProfileEnrollmentSettings struct {
+	SelfServiceRegistrationEnabled bool
+	EmailVerificationRequired      bool
+	ProgressiveProfilingAttributes []map[string]interface{}
+}
+
+//// HYDRATE FUNCTIONS
+
+func getOktaProfileEnrollmentSettings(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	if getPolicyId(h.Item) == "" {
+		return nil, nil
+	}
+
+	rules, err := listOktaPolicyRules(ctx, d, h)
+	if err != nil {
+		logger.Error("okta_profile_enrollment_policy.getOktaProfileEnrollmentSettings", "list_policy_rules_error", err)
+		return nil, err
+	}
+
+	settings := &ProfileEnrollmentSettings{
+		ProgressiveProfilingAttributes: []map[string]interface{}{},
+	}
+	activeRules, emailVerificationRules := 0, 0
+	for _, rule := range rules {
+		r, ok := rule.GetActualInstance().(*oktaV4.ProfileEnrollmentPolicyRule)
+		if !ok || r.GetStatus() != "ACTIVE" || r.Actions == nil || r.Actions.ProfileEnrollment == nil {
+			continue
+		}
+		action := r.Actions.ProfileEnrollment
+		activeRules++
+
+		if action.GetUnknownUserAction() == "REGISTER" {
+			settings.SelfServiceRegistrationEnabled = true
+		}
+		if action.ActivationRequirements != nil && action.ActivationRequirements.GetEmailVerification() {
+			emailVerificationRules++
+		}
+		if action.GetProgressiveProfilingAction() == "ENABLED" {
+			for _, attribute := range action.ProfileAttributes {
+				settings.ProgressiveProfilingAttributes = append(settings.ProgressiveProfilingAttributes, map[string]interface{}{
+					"name":     attribute.GetName(),
+					"label":    attribute.GetLabel(),
+					"required": attribute.GetRequired(),
+				})
+			}
+		}
+	}
+	settings.EmailVerificationRequired = activeRules > 0 && emailVerificationRules == activeRules
+
+	return settings, nil
+}
+
+//// TRANSFORM FUNCTION
+
+func policyMappingApps(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	mappings, ok := d.Value.([]oktaV4.PolicyMapping)
+	if !ok {
+		return nil, nil
+	}
+
+	apps := []map[string]interface{}{}
+	for _, mapping := range mappings {
+		if mapping.Links == nil || mapping.Links.Application == nil {
+			continue
+		}
+		apps = append(apps, map[string]interface{}{
+			"id":   path.Base(mapping.Links.Application.Href),
+			"name": mapping.Links.Application.Name,
+		})
+	}
+	return apps, nil
+}
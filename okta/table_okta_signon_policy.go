@@ -2,12 +2,12 @@ package okta
 
 import (
 	"context"
-	"strings"
+	"fmt"
 
 	"github.com/okta/okta-sdk-golang/v2/okta"
-	"github.com/okta/okta-sdk-golang/v2/okta/query"
 	oktaV4 "github.com/okta/okta-sdk-golang/v4/okta"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
@@ -19,8 +19,13 @@ func tableOktaSignonPolicy() *plugin.Table {
 		Name:        "okta_signon_policy",
 		Description: "Okta Sign On Policy controls the manner in which a user is allowed to sign on to Okta, including whether they are challenged for multifactor authentication (MFA) and how long they are allowed to remain signed in before re-authenticating.",
 		List: &plugin.ListConfig{
-			Hydrate: listOktaSignonPolicies,
+			Hydrate: listPolicies,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "name", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
 		},
+		HydrateConfig: policyHydrateConfig(),
 		Columns: commonColumns([]*plugin.Column{
 			// Top Columns
 			{Name: "name", Type: proto.ColumnType_STRING, Description: "Name of the Policy."},
@@ -33,9 +38,23 @@ func tableOktaSignonPolicy() *plugin.Table {
 			{Name: "priority", Type: proto.ColumnType_INT, Description: "Priority of the Policy."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "Status of the Policy: ACTIVE or INACTIVE."},
 			{Name: "system", Type: proto.ColumnType_BOOL, Description: "This is set to true on system policies, which cannot be deleted."},
+			{Name: "max_session_lifetime_minutes", Type: proto.ColumnType_INT, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("MaxSessionLifetimeMinutes"), Description: "The longest maximum session lifetime, in minutes, of the active rules that allow access. 0 means the session never expires."},
+			{Name: "max_session_idle_minutes", Type: proto.ColumnType_INT, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("MaxSessionIdleMinutes"), Description: "The longest maximum session idle time, in minutes, of the active rules that allow access."},
+			{Name: "persistent_cookie_allowed", Type: proto.ColumnType_BOOL, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("PersistentCookieAllowed"), Description: "True if any active rule that allows access lets the session cookie persist across browser sessions."},
+			{Name: "mfa_required_any_rule", Type: proto.ColumnType_BOOL, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("MfaRequiredAnyRule"), Description: "True if at least one active rule that allows access requires MFA."},
+			{Name: "mfa_required_all_rules", Type: proto.ColumnType_BOOL, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("MfaRequiredAllRules"), Description: "True if every active rule that allows access requires MFA."},
+			{Name: "network_connection", Type: proto.ColumnType_STRING, Transform: transform.FromField("Conditions.Network.Connection"), Description: "The network the Policy applies to: ANYWHERE or ZONE."},
 
 			// JSON Columns
 			{Name: "conditions", Type: proto.ColumnType_JSON, Description: "Conditions for Policy."},
+			{Name: "network_include_zones", Type: proto.ColumnType_JSON, Transform: transform.FromField("Conditions.Network.Include"), Description: "IDs of the network zones the Policy applies to."},
+			{Name: "network_exclude_zones", Type: proto.ColumnType_JSON, Transform: transform.FromField("Conditions.Network.Exclude"), Description: "IDs of the network zones the Policy does not apply to."},
+			{Name: "people_include_groups", Type: proto.ColumnType_JSON, Transform: transform.FromField("Conditions.People.Groups.Include"), Description: "IDs of the groups the Policy applies to."},
+			{Name: "people_exclude_groups", Type: proto.ColumnType_JSON, Transform: transform.FromField("Conditions.People.Groups.Exclude"), Description: "IDs of the groups the Policy does not apply to."},
+			{Name: "people_include_users", Type: proto.ColumnType_JSON, Transform: transform.FromField("Conditions.People.Users.Include"), Description: "IDs of the users the Policy applies to."},
+			{Name: "people_exclude_users", Type: proto.ColumnType_JSON, Transform: transform.FromField("Conditions.People.Users.Exclude"), Description: "IDs of the users the Policy does not apply to."},
+			{Name: "applies_to", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAppliesTo, Transform: transform.FromValue(), Description: "The groups and users included in or excluded from the Policy, with their IDs resolved to names."},
+			{Name: "network_conditions", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRuleSummary, Transform: transform.FromField("NetworkConditions"), Description: "The network conditions of the active rules, including the rule name, access, connection type and the network zones included or excluded."},
 			{Name: "rules", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyRules, Transform: transform.FromValue(), Description: "Each Policy may contain one or more Rules. Rules, like Policies, contain conditions that must be satisfied for the Rule to be applied."},
 			{Name: "resource_mapping", Type: proto.ColumnType_JSON, Hydrate: getOktaPolicyAssociatedResources, Transform: transform.FromValue(), Description: "The resources that are mapped to the Policy."},
 
@@ -45,73 +64,151 @@ func tableOktaSignonPolicy() *plugin.Table {
 	}
 }
 
-func listOktaSignonPolicies(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+//// HYDRATE FUNCTION
+
+func getOktaPolicyRules(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	client, err := Connect(ctx, d)
+	policyId := getPolicyId(h.Item)
 
-	input := &query.Params{}
+	// Empty check
+	if policyId == "" {
+		return nil, nil
+	}
+
+	rules, err := listOktaPolicyRules(ctx, d, h)
 	if err != nil {
-		logger.Error("listOktaSignonPolicies", "connect_error", err)
+		logger.Error("getOktaPolicyRules", "list_policies_error", err)
 		return nil, err
 	}
 
-	if d.Table.Name == "okta_signon_policy" {
-		input.Type = "OKTA_SIGN_ON"
+	allRules := make([]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		r := rule.GetActualInstance()
+		// We need to extract the inner properties; otherwise, the values will be populated as null.
+		result, err := structToMap(r)
+		if err != nil {
+			logger.Error("getOktaPolicyRules", "error in parsing the rules for the policy:", policyId, err)
+			return nil, err
+		}
+		allRules = append(allRules, result)
+	}
+
+	return allRules, nil
+}
+
+type PolicyRuleSummary struct {
+	MaxSessionLifetimeMinutes *int32
+	MaxSessionIdleMinutes     *int32
+	PersistentCookieAllowed   bool
+	MfaRequiredAnyRule        bool
+	MfaRequiredAllRules       bool
+	NetworkConditions         []map[string]interface{}
+}
+
+// getOktaPolicyRuleSummary summarizes the behaviour of the active rules of a
+// sign-on or access policy
+func getOktaPolicyRuleSummary(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	policyId := getPolicyId(h.Item)
+
+	// Empty check
+	if policyId == "" {
+		return nil, nil
 	}
 
-	policies, resp, err := client.Policy.ListPolicies(ctx, input)
+	rules, err := listOktaPolicyRules(ctx, d, h)
 	if err != nil {
-		logger.Error("listOktaSignonPolicies", "list_policies_error", err)
+		logger.Error("getOktaPolicyRuleSummary", "list_policies_error", err)
 		return nil, err
 	}
 
-	for _, policy := range policies {
-		d.StreamListItem(ctx, policy)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
+	summary := &PolicyRuleSummary{
+		NetworkConditions: []map[string]interface{}{},
 	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextPolicySet []*okta.Policy
-		resp, err = resp.Next(ctx, &nextPolicySet)
-		if err != nil {
-			logger.Error("listOktaSignonPolicies", "list_policies_paging_error", err)
-			return nil, err
+	allowRules, mfaRules := 0, 0
+	for _, rule := range rules {
+		var name, status, access string
+		var requireFactor bool
+		var network *oktaV4.PolicyNetworkCondition
+
+		switch r := rule.GetActualInstance().(type) {
+		case *oktaV4.OktaSignOnPolicyRule:
+			name, status = r.GetName(), r.GetStatus()
+			if r.Conditions != nil {
+				network = r.Conditions.Network
+			}
+			signon := r.GetActions().Signon
+			if signon == nil {
+				continue
+			}
+			access = signon.GetAccess()
+			requireFactor = signon.GetRequireFactor()
+			if status == "ACTIVE" && access == "ALLOW" && signon.Session != nil {
+				summary.MaxSessionLifetimeMinutes = longestSessionMinutes(summary.MaxSessionLifetimeMinutes, signon.Session.MaxSessionLifetimeMinutes)
+				summary.MaxSessionIdleMinutes = longestSessionMinutes(summary.MaxSessionIdleMinutes, signon.Session.MaxSessionIdleMinutes)
+				if signon.Session.GetUsePersistentCookie() {
+					summary.PersistentCookieAllowed = true
+				}
+			}
+		case *oktaV4.AccessPolicyRule:
+			name, status = r.GetName(), r.GetStatus()
+			if r.Conditions != nil {
+				network = r.Conditions.Network
+			}
+			appSignOn := r.GetActions().AppSignOn
+			if appSignOn == nil {
+				continue
+			}
+			access = appSignOn.GetAccess()
+			requireFactor = appSignOn.VerificationMethod != nil && appSignOn.VerificationMethod.GetFactorMode() == "2FA"
+		default:
+			continue
 		}
-		for _, policy := range nextPolicySet {
-			d.StreamListItem(ctx, policy)
 
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
+		if status != "ACTIVE" {
+			continue
+		}
+		if network != nil {
+			summary.NetworkConditions = append(summary.NetworkConditions, map[string]interface{}{
+				"rule_name":  name,
+				"access":     access,
+				"connection": network.GetConnection(),
+				"include":    network.Include,
+				"exclude":    network.Exclude,
+			})
+		}
+		if access != "ALLOW" {
+			continue
+		}
+		allowRules++
+		if requireFactor {
+			mfaRules++
 		}
 	}
+	summary.MfaRequiredAnyRule = mfaRules > 0
+	summary.MfaRequiredAllRules = allowRules > 0 && mfaRules == allowRules
 
-	return nil, err
+	return summary, nil
 }
 
-//// HYDRATE FUNCTION
+// if the caching is required other than per connection, build a cache key for the call and use it in Memoize.
+var getOktaPolicyAssociatedResourcesMemoized = plugin.HydrateFunc(getOktaPolicyAssociatedResourcesUncached).Memoize(memoize.WithCacheKeyFunction(getOktaPolicyAssociatedResourcesCacheKey))
 
-func getOktaPolicyRules(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	logger := plugin.Logger(ctx)
-	if h.Item == nil {
-		return nil, nil
-	}
-	policyId := ""
+// declare a wrapper hydrate function to call the memoized function
+// - this is required when a memoized function is used for a column definition
+func getOktaPolicyAssociatedResources(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaPolicyAssociatedResourcesMemoized(ctx, d, h)
+}
 
-	switch item := h.Item.(type) {
-	case *PolicyStructure:
-		policyId = item.Id
-	case *okta.Policy:
-		policyId = item.Id
-	case *okta.AuthorizationServerPolicy:
-		policyId = item.Id
-	}
+// Build a cache key for the call to getOktaPolicyAssociatedResources.
+func getOktaPolicyAssociatedResourcesCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("getOktaPolicyAssociatedResources-%s", getPolicyId(h.Item))
+	return key, nil
+}
+
+func getOktaPolicyAssociatedResourcesUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	policyId := getPolicyId(h.Item)
 
 	// Empty check
 	if policyId == "" {
@@ -120,101 +217,252 @@ func getOktaPolicyRules(ctx context.Context, d *plugin.QueryData, h *plugin.Hydr
 
 	client, err := ConnectV4(ctx, d)
 	if err != nil {
-		logger.Error("getOktaPolicyRules", "connect_error", err)
+		logger.Error("getOktaPolicyAssociatedResources", "connect_error", err)
 		return nil, err
 	}
 
-	var rules []oktaV4.ListPolicyRules200ResponseInner
+	policyMappings, resp, err := client.PolicyAPI.ListPolicyMappings(ctx, policyId).Execute()
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		logger.Error("getOktaPolicyAssociatedResources", "list_policies_error", err)
+		return nil, err
+	}
 
-	policyRules, resp, err := client.PolicyAPI.ListPolicyRules(ctx, policyId).Execute()
+	mappings, err := collectPages(ctx, policyMappings, v4Pages[oktaV4.PolicyMapping](resp))
 	if err != nil {
-		logger.Error("getOktaPolicyRules", "list_policies_error", err)
+		logger.Error("getOktaPolicyAssociatedResources", "list_policies_paging_error", err)
 		return nil, err
 	}
 
-	rules = append(rules, policyRules...)
+	return mappings, nil
+}
 
-	// paging
-	for resp.HasNextPage() {
-		var nextPolicyRules []*oktaV4.ListPolicyRules200ResponseInner
-		resp, err = resp.Next(&nextPolicyRules)
-		if err != nil {
-			logger.Error("getOktaPolicyRules", "list_policies_paging_error", err)
+type PolicyAppliesTo struct {
+	Groups PolicyPrincipals `json:"groups"`
+	Users  PolicyPrincipals `json:"users"`
+}
+
+type PolicyPrincipals struct {
+	Include []PolicyPrincipal `json:"include"`
+	Exclude []PolicyPrincipal `json:"exclude"`
+}
+
+type PolicyPrincipal struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// getOktaPolicyAppliesTo resolves the group and user IDs of the people
+// condition of a policy to their names
+func getOktaPolicyAppliesTo(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	var conditions *okta.PolicyRuleConditions
+	switch item := h.Item.(type) {
+	case *PolicyStructure:
+		conditions = item.Conditions
+	case *okta.Policy:
+		conditions = item.Conditions
+	}
+	if conditions == nil || conditions.People == nil {
+		return nil, nil
+	}
+
+	appliesTo := &PolicyAppliesTo{
+		Groups: PolicyPrincipals{Include: []PolicyPrincipal{}, Exclude: []PolicyPrincipal{}},
+		Users:  PolicyPrincipals{Include: []PolicyPrincipal{}, Exclude: []PolicyPrincipal{}},
+	}
+
+	resolve := func(ids []string, lookup plugin.HydrateFunc) ([]PolicyPrincipal, error) {
+		principals := []PolicyPrincipal{}
+		for _, id := range ids {
+			name, err := lookup(ctx, d, &plugin.HydrateData{Item: id})
+			if err != nil {
+				return nil, err
+			}
+			principal := PolicyPrincipal{Id: id}
+			if name != nil {
+				principal.Name = name.(string)
+			}
+			principals = append(principals, principal)
+		}
+		return principals, nil
+	}
+
+	var err error
+	if groups := conditions.People.Groups; groups != nil {
+		if appliesTo.Groups.Include, err = resolve(groups.Include, getOktaGroupName); err != nil {
+			logger.Error("getOktaPolicyAppliesTo", "get_group_error", err)
 			return nil, err
 		}
-		for _, r := range nextPolicyRules {
-			rules = append(rules, *r)
+		if appliesTo.Groups.Exclude, err = resolve(groups.Exclude, getOktaGroupName); err != nil {
+			logger.Error("getOktaPolicyAppliesTo", "get_group_error", err)
+			return nil, err
 		}
 	}
-
-	var allRules []interface{}
-	for _, rule := range rules {
-		r := rule.GetActualInstance()
-		// We need to extract the inner properties; otherwise, the values will be populated as null.
-		result, err := structToMap(r)
-		if err != nil {
-			logger.Error("getOktaPolicyRules", "error in parsing the rules for the policy:", policyId, err)
+	if users := conditions.People.Users; users != nil {
+		if appliesTo.Users.Include, err = resolve(users.Include, getOktaUserLogin); err != nil {
+			logger.Error("getOktaPolicyAppliesTo", "get_user_error", err)
+			return nil, err
+		}
+		if appliesTo.Users.Exclude, err = resolve(users.Exclude, getOktaUserLogin); err != nil {
+			logger.Error("getOktaPolicyAppliesTo", "get_user_error", err)
 			return nil, err
 		}
-		allRules = append(allRules, result)
 	}
 
-	return allRules, nil
+	return appliesTo, nil
 }
 
-func getOktaPolicyAssociatedResources(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
-	logger := plugin.Logger(ctx)
-	if h.Item == nil {
+// Group and user names are looked up by ID, so cache each lookup since the
+// same groups are usually referenced by many policies
+var getOktaGroupNameMemoized = plugin.HydrateFunc(getOktaGroupNameUncached).Memoize(memoize.WithCacheKeyFunction(getOktaPrincipalNameCacheKey))
+
+func getOktaGroupName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaGroupNameMemoized(ctx, d, h)
+}
+
+var getOktaUserLoginMemoized = plugin.HydrateFunc(getOktaUserLoginUncached).Memoize(memoize.WithCacheKeyFunction(getOktaPrincipalNameCacheKey))
+
+func getOktaUserLogin(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaUserLoginMemoized(ctx, d, h)
+}
+
+// Build a cache key for the calls to getOktaGroupName and getOktaUserLogin.
+// Okta group and user IDs have distinct prefixes, so the ID alone is unique.
+func getOktaPrincipalNameCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("getOktaPrincipalName-%s", h.Item.(string))
+	return key, nil
+}
+
+func getOktaGroupNameUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	group, _, err := client.Group.GetGroup(ctx, h.Item.(string))
+	if err != nil {
+		// The group may have been deleted since the policy was last updated
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if group.Profile == nil {
 		return nil, nil
 	}
-	policyId := ""
 
-	switch item := h.Item.(type) {
+	return group.Profile.Name, nil
+}
+
+func getOktaUserLoginUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	user, _, err := client.User.GetUser(ctx, h.Item.(string))
+	if err != nil {
+		// The user may have been deleted since the policy was last updated
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if user.Profile == nil {
+		return nil, nil
+	}
+
+	return (*user.Profile)["login"], nil
+}
+
+//// UTILITY FUNCTION
+
+// getPolicyId extracts the policy ID from any of the policy representations
+// streamed by the policy tables, including the v4 SDK union types
+func getPolicyId(item interface{}) string {
+	switch item := item.(type) {
 	case *PolicyStructure:
-		policyId = item.Id
+		return item.Id
 	case *okta.Policy:
-		policyId = item.Id
+		return item.Id
 	case *okta.AuthorizationServerPolicy:
-		policyId = item.Id
+		return item.Id
+	case oktaV4.ListPolicies200ResponseInner:
+		return getPolicyId(&item)
+	case interface{ GetId() string }:
+		return item.GetId()
+	case unionType:
+		var policy struct {
+			Id string `json:"id"`
+		}
+		if err := decodeUnionInstance(item, &policy); err != nil {
+			return ""
+		}
+		return policy.Id
 	}
+	return ""
+}
 
-	// Empty check
-	if policyId == "" {
-		return nil, nil
+// ListPolicyRules backs several columns of every policy table, so the rules
+// are cached per policy rather than fetched once per hydrate function
+var listOktaPolicyRulesMemoized = plugin.HydrateFunc(listOktaPolicyRulesUncached).Memoize(memoize.WithCacheKeyFunction(getOktaPolicyRulesCacheKey))
+
+func listOktaPolicyRules(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) ([]oktaV4.ListPolicyRules200ResponseInner, error) {
+	rules, err := listOktaPolicyRulesMemoized(ctx, d, h)
+	if err != nil || rules == nil {
+		return nil, err
 	}
+	return rules.([]oktaV4.ListPolicyRules200ResponseInner), nil
+}
+
+// Build a cache key for the call to listOktaPolicyRules.
+func getOktaPolicyRulesCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := fmt.Sprintf("listOktaPolicyRules-%s", getPolicyId(h.Item))
+	return key, nil
+}
 
+func listOktaPolicyRulesUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
 	client, err := ConnectV4(ctx, d)
 	if err != nil {
-		logger.Error("getOktaPolicyAssociatedResources", "connect_error", err)
 		return nil, err
 	}
 
-	var mappings []oktaV4.PolicyMapping
-
-	policyMappings, resp, err := client.PolicyAPI.ListPolicyMappings(ctx, policyId).Execute()
+	rules, resp, err := client.PolicyAPI.ListPolicyRules(ctx, getPolicyId(h.Item)).Execute()
 	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "not found") || strings.Contains(err.Error(), "404") {
-			return nil, nil
-		}
-		logger.Error("getOktaPolicyAssociatedResources", "list_policies_error", err)
 		return nil, err
 	}
 
-	mappings = append(mappings, policyMappings...)
-
-	// paging
-	for resp.HasNextPage() {
-		var nextPolicyMappings []*oktaV4.PolicyMapping
-		resp, err = resp.Next(&nextPolicyMappings)
-		if err != nil {
-			logger.Error("getOktaPolicyAssociatedResources", "list_policies_paging_error", err)
-			return nil, err
-		}
+	return collectPages(ctx, rules, v4Pages[oktaV4.ListPolicyRules200ResponseInner](resp))
+}
 
-		for _, mapping := range nextPolicyMappings {
-			mappings = append(mappings, *mapping)
-		}
+// policyHydrateConfig bounds the number of concurrent rule and mapping calls
+// made for each policy table
+func policyHydrateConfig() []plugin.HydrateConfig {
+	return []plugin.HydrateConfig{
+		{Func: getOktaPolicyRules, MaxConcurrency: 10},
+		{Func: getOktaPolicyRuleSummary, MaxConcurrency: 10},
+		{Func: getOktaPolicyAssociatedResources, MaxConcurrency: 10},
+		{Func: getOktaPolicyAppliesTo, MaxConcurrency: 10},
 	}
+}
 
-	return mappings, nil
+// longestSessionMinutes returns the longer of two session durations, where 0
+// means the session never expires
+func longestSessionMinutes(current *int32, value *int32) *int32 {
+	if value == nil {
+		return current
+	}
+	if current == nil {
+		return value
+	}
+	if *current == 0 {
+		return current
+	}
+	if *value == 0 || *value > *current {
+		return value
+	}
+	return current
 }
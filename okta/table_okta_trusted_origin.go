@@ -18,9 +18,9 @@ func tableOktaTrustedOrigin() *plugin.Table {
 		Name:        "okta_trusted_origin",
 		Description: "Trusted Origin is a security-based concept that combines the URI scheme, hostname, and port number of a page.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaTrustedOrigin,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaTrustedOrigin,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaTrustedOrigins,
@@ -37,6 +37,7 @@ func tableOktaTrustedOrigin() *plugin.Table {
 			{Name: "last_updated_by", Type: proto.ColumnType_STRING, Description: "The ID of the user who last updated the trusted origin."},
 			{Name: "origin", Type: proto.ColumnType_STRING, Description: "The origin of the trusted origin."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "Current status of the trusted origin. Valid values are 'ACTIVE' or 'INACTIVE'."},
+			{Name: "self_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "self"), Description: "A self-referential link to the trusted origin."},
 
 			// JSON Columns
 			{Name: "scopes", Type: proto.ColumnType_JSON, Description: "The scopes for the trusted origin. Valid values are 'CORS' or 'REDIRECT'."},
@@ -60,16 +61,7 @@ func listOktaTrustedOrigins(ctx context.Context, d *plugin.QueryData, _ *plugin.
 	// Maximum limit isn't mentioned in the documentation
 	// Default maximum limit is set as 200
 	input := query.Params{
-		Limit: 200,
-	}
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
+		Limit: getListPageSize(d, 200),
 	}
 
 	origins, resp, err := client.TrustedOrigin.ListOrigins(ctx, &input)
@@ -78,34 +70,13 @@ func listOktaTrustedOrigins(ctx context.Context, d *plugin.QueryData, _ *plugin.
 		return nil, err
 	}
 
-	for _, origin := range origins {
-		d.StreamListItem(ctx, origin)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextOriginSet []*okta.TrustedOrigin
-		resp, err = resp.Next(ctx, &nextOriginSet)
-		if err != nil {
-			logger.Error("listOktaTrustedOrigins", "list_origins_paging_error", err)
-			return nil, err
-		}
-		for _, origin := range nextOriginSet {
-			d.StreamListItem(ctx, origin)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
+	err = streamPages(ctx, d, origins, v2Pages[*okta.TrustedOrigin](ctx, resp), nil)
+	if err != nil {
+		logger.Error("listOktaTrustedOrigins", "list_origins_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTIONS
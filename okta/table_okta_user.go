@@ -3,12 +3,15 @@ package okta
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/ettle/strcase"
 	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/okta/okta-sdk-golang/v2/okta/query"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/memoize"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -16,14 +19,14 @@ import (
 
 //// TABLE DEFINITION
 
-func tableOktaUser() *plugin.Table {
+func tableOktaUser(ctx context.Context, td *plugin.TableMapData) *plugin.Table {
 	return &plugin.Table{
 		Name:        "okta_user",
 		Description: "Represents an Okta user account.",
 		Get: &plugin.GetConfig{
-			Hydrate:           getOktaUser,
-			KeyColumns:        plugin.SingleColumn("id"),
-			ShouldIgnoreError: isNotFoundError([]string{"Not found"}),
+			Hydrate:      getOktaUser,
+			KeyColumns:   plugin.SingleColumn("id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
 		},
 		List: &plugin.ListConfig{
 			Hydrate: listOktaUsers,
@@ -36,7 +39,14 @@ func tableOktaUser() *plugin.Table {
 				{Name: "email", Require: plugin.Optional},
 				{Name: "status", Require: plugin.Optional},
 				{Name: "filter", Require: plugin.Optional},
+				{Name: "search", Require: plugin.Optional},
+				{Name: "user_type_id", Require: plugin.Optional},
 				{Name: "last_updated", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
+				{Name: "created", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
+				{Name: "activated", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
+				{Name: "last_login", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
+				{Name: "password_changed", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
+				{Name: "status_changed", Operators: []string{">", ">=", "=", "<", "<="}, Require: plugin.Optional},
 			},
 		},
 		HydrateConfig: []plugin.HydrateConfig{
@@ -48,34 +58,57 @@ func tableOktaUser() *plugin.Table {
 				Func: listAssignedRolesForUser,
 				MaxConcurrency: 10,
 			},
+			{
+				Func:           listUserAppLinks,
+				MaxConcurrency: 10,
+			},
+			{
+				Func:           getUserMfaSummary,
+				MaxConcurrency: 10,
+			},
 		},
-		Columns: commonColumns([]*plugin.Column{
+		Columns: commonColumns(append([]*plugin.Column{
 			// Top Columns
 			{Name: "login", Type: proto.ColumnType_STRING, Transform: transform.From(userProfile), Description: "Unique identifier for the user (username)."},
 			{Name: "id", Type: proto.ColumnType_STRING, Description: "Unique key for user."},
 			{Name: "email", Type: proto.ColumnType_STRING, Transform: transform.From(userProfile), Description: "Primary email address of user."},
-			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when user was created."},
+			{Name: "created", Type: proto.ColumnType_TIMESTAMP, Sort: plugin.SortAll, Description: "Timestamp when user was created."},
 			{Name: "filter", Type: proto.ColumnType_STRING, Transform: transform.FromQual("filter"), Description: "Filter string to [filter](https://developer.okta.com/docs/reference/api/users/#list-users-with-a-filter) users. Input filter query should not be encoded."},
+			{Name: "search", Type: proto.ColumnType_STRING, Transform: transform.FromQual("search"), Description: "Search expression to [search](https://developer.okta.com/docs/reference/api/users/#list-users-with-search) users, including on custom profile attributes. Input search query should not be encoded."},
 
 			// Other Columns
-			{Name: "activated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when transition to ACTIVE status completed."},
-			{Name: "last_login", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp of last login."},
-			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when user was last updated."},
-			{Name: "password_changed", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when password last changed."},
-			{Name: "self_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links.self.href"), Description: "A self-referential link to this user."},
+			{Name: "activated", Type: proto.ColumnType_TIMESTAMP, Sort: plugin.SortAll, Description: "Timestamp when transition to ACTIVE status completed."},
+			{Name: "last_login", Type: proto.ColumnType_TIMESTAMP, Sort: plugin.SortAll, Description: "Timestamp of last login."},
+			{Name: "last_updated", Type: proto.ColumnType_TIMESTAMP, Sort: plugin.SortAll, Description: "Timestamp when user was last updated."},
+			{Name: "password_changed", Type: proto.ColumnType_TIMESTAMP, Sort: plugin.SortAll, Description: "Timestamp when password last changed."},
+			{Name: "password_age_days", Type: proto.ColumnType_INT, Transform: transform.FromField("PasswordChanged").Transform(daysSince), Description: "Number of whole days since the password was last changed. Null if the user has never set a password."},
+			{Name: "self_link", Type: proto.ColumnType_STRING, Transform: transform.FromField("Links").TransformP(linkHref, "self"), Description: "A self-referential link to this user."},
 			{Name: "status", Type: proto.ColumnType_STRING, Description: "Current status of user. Can be one of the STAGED, PROVISIONED, ACTIVE, RECOVERY, LOCKED_OUT, PASSWORD_EXPIRED, SUSPENDED, or DEPROVISIONED."},
-			{Name: "status_changed", Type: proto.ColumnType_TIMESTAMP, Description: "Timestamp when status last changed."},
-			{Name: "transitioning_to_status", Type: proto.ColumnType_STRING, Description: "Target status of an in-progress asynchronous status transition."},
+			{Name: "status_changed", Type: proto.ColumnType_TIMESTAMP, Sort: plugin.SortAll, Description: "Timestamp when status last changed."},
+			{Name: "transitioning_to_status", Type: proto.ColumnType_STRING, Transform: transform.FromField("TransitioningToStatus").NullIfZero(), Description: "Target status of an in-progress asynchronous status transition. Null if no transition is in progress."},
+			{Name: "is_locked_out", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Status").Transform(isUserLockedOut), Description: "True if the user is locked out after too many failed sign-in attempts."},
+			{Name: "credential_provider_type", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.Provider.Type"), Description: "The type of the provider that authenticates the user's password: OKTA, ACTIVE_DIRECTORY, LDAP, FEDERATION, SOCIAL or IMPORT."},
+			{Name: "credential_provider_name", Type: proto.ColumnType_STRING, Transform: transform.FromField("Credentials.Provider.Name"), Description: "The name of the provider that authenticates the user's password."},
+			{Name: "has_password", Type: proto.ColumnType_BOOL, Transform: transform.From(userHasPassword), Description: "True if the user has a password credential."},
+			{Name: "has_recovery_question", Type: proto.ColumnType_BOOL, Transform: transform.From(userHasRecoveryQuestion), Description: "True if the user has enrolled a recovery question."},
+			{Name: "password_federated", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Credentials.Provider.Type").Transform(isPasswordFederated), Description: "True if the user's password is managed by an external provider rather than Okta."},
+			{Name: "password_imported", Type: proto.ColumnType_BOOL, Transform: transform.FromField("Credentials.Provider.Type").Transform(isPasswordImported), Description: "True if the user's password is verified by a password import inline hook."},
+			{Name: "mfa_enrolled", Type: proto.ColumnType_BOOL, Hydrate: getUserMfaSummary, Transform: transform.FromField("ActiveFactorCount").Transform(isMfaEnrolled), Description: "True if the user has at least one active MFA factor."},
+			{Name: "enrolled_factor_count", Type: proto.ColumnType_INT, Hydrate: getUserMfaSummary, Transform: transform.FromField("ActiveFactorCount"), Description: "The number of active MFA factors enrolled by the user."},
+			{Name: "user_type_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Type.Id"), Description: "Unique key for the user type of the user."},
+			{Name: "user_type_name", Type: proto.ColumnType_STRING, Hydrate: getUserTypeName, Transform: transform.FromValue(), Description: "Name of the user type of the user."},
 
 			// JSON Columns
 			{Name: "profile", Type: proto.ColumnType_JSON, Description: "User profile properties."},
 			{Name: "type", Type: proto.ColumnType_JSON, Description: "User type that determines the schema for the user's profile."},
-			{Name: "user_groups", Type: proto.ColumnType_JSON, Hydrate: listUserGroups, Transform: transform.From(transformUserGroups), Description: "List of groups of which the user is a member."},
+			{Name: "user_groups", Type: proto.ColumnType_JSON, Hydrate: listUserGroups, Transform: transform.FromValue(), Description: "List of groups of which the user is a member."},
 			{Name: "assigned_roles", Type: proto.ColumnType_JSON, Hydrate: listAssignedRolesForUser, Transform: transform.FromValue(), Description: "List of roles assigned to user."},
+			{Name: "app_links", Type: proto.ColumnType_JSON, Hydrate: listUserAppLinks, Transform: transform.From(transformUserAppLinks), Description: "List of application links assigned to the user, including the app ID, label and sign-in link."},
+			{Name: "roles", Type: proto.ColumnType_JSON, Hydrate: listAssignedRolesForUser, Transform: transform.From(transformUserRoleTypes), Description: "List of the distinct admin role types in assigned_roles, for example SUPER_ADMIN or ORG_ADMIN."},
 
 			// Steampipe Columns
 			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.From(userProfile), Description: titleDescription},
-		}),
+		}, userProfileColumns(ctx, td)...)),
 	}
 }
 
@@ -83,86 +116,36 @@ func tableOktaUser() *plugin.Table {
 
 func listOktaUsers(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
 	logger := plugin.Logger(ctx)
-	client, err := Connect(ctx, d)
-	if err != nil {
-		logger.Error("listOktaUsers", "connect_error", err)
-		return nil, err
-	}
 
 	// Default maximum limit set as per documentation
 	// https://developer.okta.com/docs/reference/api/users/#request-parameters-3
 	input := query.Params{
-		Limit: 200,
-	}
-
-	// If the requested number of items is less than the paging max limit
-	// set the limit to that instead
-	limit := d.QueryContext.Limit
-	if d.QueryContext.Limit != nil {
-		if *limit < input.Limit {
-			input.Limit = *limit
-		}
-	}
-
-	equalQuals := d.EqualsQuals
-	quals := d.Quals
-
-	var queryFilter string
-	filter := buildUserQueryFilter(equalQuals)
-
-	// TODO - optimize or move it to a utility function
-	// https://developer.okta.com/docs/reference/api-overview/#operators
-	if quals["last_updated"] != nil {
-		for _, q := range quals["last_updated"].Quals {
-			timeString := q.Value.GetTimestampValue().AsTime().Format(filterTimeFormat)
-			filter = append(filter, fmt.Sprintf("%s %s \"%s\"", "lastUpdated", operatorsMap[q.Operator], timeString))
-		}
+		Limit: getListPageSize(d, 200),
 	}
 
-	if equalQuals["filter"] != nil {
-		queryFilter = equalQuals["filter"].GetStringValue()
-	}
-
-	if queryFilter != "" {
-		input.Filter = queryFilter
-	} else if len(filter) > 0 {
-		input.Filter = strings.Join(filter, " and ")
+	// The quals of tables listing users as their parent, e.g. the status of
+	// okta_factor, aren't user attributes, so all users are listed for them
+	if d.Table.Name == "okta_user" || d.Table.Name == "okta_user_mfa_summary" {
+		setUserListQuery(d, &input)
+	} else {
+		input.Limit = getPageSize(d, 200)
 	}
 
-	users, resp, err := client.User.ListUsers(ctx, &input)
+	users, resp, err := listOktaUsersPage(ctx, d, "/api/v1/users"+input.String())
 	if err != nil {
 		logger.Error("listOktaUsers", "list_users_error", err)
 		return nil, err
 	}
 
-	for _, user := range users {
-		d.StreamListItem(ctx, user)
-
-		// Context can be cancelled due to manual cancellation or the limit has been hit
-		if d.RowsRemaining(ctx) == 0 {
-			return nil, nil
-		}
-	}
-
-	// paging
-	for resp.HasNextPage() {
-		var nextUserSet []*okta.User
-		resp, err = resp.Next(ctx, &nextUserSet)
-		if err != nil {
-			logger.Error("listOktaUsers", "list_users_paging_error", err)
-			return nil, err
-		}
-		for _, user := range nextUserSet {
-			d.StreamListItem(ctx, user)
-
-			// Context can be cancelled due to manual cancellation or the limit has been hit
-			if d.RowsRemaining(ctx) == 0 {
-				return nil, nil
-			}
-		}
+	err = streamPages(ctx, d, users, urlPages(resp, func(url string) ([]*okta.User, *okta.Response, error) {
+		return listOktaUsersPage(ctx, d, url)
+	}), nil)
+	if err != nil {
+		logger.Error("listOktaUsers", "list_users_paging_error", err)
+		return nil, err
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 //// HYDRATE FUNCTIONS
@@ -181,6 +164,12 @@ func getOktaUser(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData
 		return nil, nil
 	}
 
+	release, err := acquireConnectionSemaphore(ctx, d, "get_user", getConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	client, err := Connect(ctx, d)
 	if err != nil {
 		logger.Error("getOktaUser", "connect_error", err)
@@ -209,23 +198,24 @@ func listUserGroups(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateD
 	groups, resp, err := client.User.ListUserGroups(ctx, user.Id)
 	if err != nil {
 		logger.Error("listUserGroups", "list_user_groups_error", err)
-		if strings.Contains(err.Error(), "Not found") {
+		if isNotFoundError(err) {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	for resp.HasNextPage() {
-		var nextGroupSet []*okta.Group
-		resp, err = resp.Next(ctx, &nextGroupSet)
-		if err != nil {
-			logger.Error("listUserGroups", "list_user_groups_paging_error", err)
-			return nil, err
-		}
-		groups = append(groups, nextGroupSet...)
+	// Only the fields of the user_groups column are kept from each page
+	userGroups := make([]map[string]string, 0, len(groups))
+	err = forEachPage(ctx, groups, v2Pages[*okta.Group](ctx, resp), func(page []*okta.Group) bool {
+		userGroups = appendUserGroups(userGroups, page)
+		return true
+	})
+	if err != nil {
+		logger.Error("listUserGroups", "list_user_groups_paging_error", err)
+		return nil, err
 	}
 
-	return groups, nil
+	return userGroups, nil
 }
 
 func listAssignedRolesForUser(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
@@ -244,19 +234,117 @@ func listAssignedRolesForUser(ctx context.Context, d *plugin.QueryData, h *plugi
 		return nil, err
 	}
 
-	for resp.HasNextPage() {
-		var nextRolesSet []*okta.Role
-		resp, err = resp.Next(ctx, &nextRolesSet)
-		if err != nil {
-			logger.Error("listAssignedRolesForUser", "list_assigned_roles_for_user_paging_error", err)
-			return nil, err
-		}
-		roles = append(roles, nextRolesSet...)
+	roles, err = collectPages(ctx, roles, v2Pages[*okta.Role](ctx, resp))
+	if err != nil {
+		logger.Error("listAssignedRolesForUser", "list_assigned_roles_for_user_paging_error", err)
+		return nil, err
 	}
 
 	return roles, nil
 }
 
+func listUserAppLinks(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	logger.Trace("listUserAppLinks")
+	user := h.Item.(*okta.User)
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("listUserAppLinks", "connect_error", err)
+		return nil, err
+	}
+
+	appLinks, _, err := client.User.ListAppLinks(ctx, user.Id)
+	if err != nil {
+		logger.Error("listUserAppLinks", "list_app_links_error", err)
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return appLinks, nil
+}
+
+func getUserTypeName(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	user := h.Item.(*okta.User)
+	if user.Type == nil || user.Type.Id == "" {
+		return nil, nil
+	}
+
+	userTypeNames, err := getOktaUserTypeNames(ctx, d, h)
+	if err != nil {
+		plugin.Logger(ctx).Error("getUserTypeName", "list_user_types_error", err)
+		return nil, err
+	}
+
+	if name, ok := userTypeNames.(map[string]string)[user.Type.Id]; ok {
+		return name, nil
+	}
+	return nil, nil
+}
+
+var listOktaUsersPageMemoized = plugin.HydrateFunc(listOktaUsersPageUncached).Memoize(memoize.WithCacheKeyFunction(getListPageCacheKey), memoize.WithTtl(listPageCacheTtl))
+
+// listOktaUsersPage returns the page of users at the URL, shared by the scans
+// of the query listing the same users
+func listOktaUsersPage(ctx context.Context, d *plugin.QueryData, url string) ([]*okta.User, *okta.Response, error) {
+	page, err := getListPage(ctx, d, url, listOktaUsersPageMemoized, listOktaUsersPageUncached)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page.items.([]*okta.User), page.resp, nil
+}
+
+func listOktaUsersPageUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*okta.User
+
+	resp, err := listOktaPage(ctx, client, h.Item.(string), &users)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listPage{items: users, resp: resp}, nil
+}
+
+// if the caching is required other than per connection, build a cache key for the call and use it in Memoize.
+var getOktaUserTypeNamesMemoized = plugin.HydrateFunc(getOktaUserTypeNamesUncached).Memoize(memoize.WithCacheKeyFunction(getOktaUserTypeNamesCacheKey))
+
+// declare a wrapper hydrate function to call the memoized function
+// - this is required when a memoized function is used for a column definition
+func getOktaUserTypeNames(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	return getOktaUserTypeNamesMemoized(ctx, d, h)
+}
+
+// Build a cache key for the call to getOktaUserTypeNames.
+func getOktaUserTypeNamesCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	key := "getOktaUserTypeNames"
+	return key, nil
+}
+
+func getOktaUserTypeNamesUncached(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	client, err := Connect(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	userTypes, _, err := client.UserType.ListUserTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userTypeNames := map[string]string{}
+	for _, userType := range userTypes {
+		userTypeNames[userType.Id] = userType.Name
+	}
+
+	return userTypeNames, nil
+}
+
 //// TRANSFORM FUNCTION
 
 func userProfile(ctx context.Context, d *transform.TransformData) (interface{}, error) {
@@ -273,23 +361,190 @@ func userProfile(ctx context.Context, d *transform.TransformData) (interface{},
 	return userProfile[strcase.ToCamel(columnName)], nil
 }
 
-func transformUserGroups(ctx context.Context, d *transform.TransformData) (interface{}, error) {
-	groups := d.HydrateItem.([]*okta.Group)
-	var groupsData = []map[string]string{}
+func userProfileAttribute(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	user := d.HydrateItem.(*okta.User)
+	if user.Profile == nil {
+		return nil, nil
+	}
+	return (*user.Profile)[d.Param.(string)], nil
+}
 
+func appendUserGroups(userGroups []map[string]string, groups []*okta.Group) []map[string]string {
 	for _, group := range groups {
-		groupsData = append(groupsData, map[string]string{
+		userGroups = append(userGroups, map[string]string{
 			"id":   group.Id,
 			"name": group.Profile.Name,
 			"type": group.Type,
 		})
 	}
 
-	return groupsData, nil
+	return userGroups
+}
+
+func userHasPassword(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	user := d.HydrateItem.(*okta.User)
+	return user.Credentials != nil && user.Credentials.Password != nil, nil
+}
+
+func userHasRecoveryQuestion(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	user := d.HydrateItem.(*okta.User)
+	return user.Credentials != nil && user.Credentials.RecoveryQuestion != nil && user.Credentials.RecoveryQuestion.Question != "", nil
+}
+
+func isPasswordFederated(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	providerType, ok := d.Value.(string)
+	if !ok || providerType == "" {
+		return nil, nil
+	}
+	return providerType != "OKTA" && providerType != "IMPORT", nil
+}
+
+func isPasswordImported(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	providerType, ok := d.Value.(string)
+	if !ok || providerType == "" {
+		return nil, nil
+	}
+	return providerType == "IMPORT", nil
+}
+
+func isMfaEnrolled(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	count, ok := d.Value.(int)
+	if !ok {
+		return nil, nil
+	}
+	return count > 0, nil
+}
+
+func isUserLockedOut(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	return d.Value == "LOCKED_OUT", nil
+}
+
+// daysSince returns the number of whole days elapsed since a timestamp
+func daysSince(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	timestamp, ok := d.Value.(*time.Time)
+	if !ok || timestamp == nil || timestamp.IsZero() {
+		return nil, nil
+	}
+	return int64(time.Since(*timestamp).Hours() / 24), nil
+}
+
+func transformUserAppLinks(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	appLinks, ok := d.HydrateItem.([]*okta.AppLink)
+	if !ok {
+		return nil, nil
+	}
+	var appLinksData = []map[string]interface{}{}
+
+	for _, appLink := range appLinks {
+		appLinksData = append(appLinksData, map[string]interface{}{
+			"app_id":   appLink.AppInstanceId,
+			"app_name": appLink.AppName,
+			"label":    appLink.Label,
+			"href":     appLink.LinkUrl,
+			"hidden":   appLink.Hidden,
+		})
+	}
+
+	return appLinksData, nil
+}
+
+func transformUserRoleTypes(ctx context.Context, d *transform.TransformData) (interface{}, error) {
+	roles, ok := d.HydrateItem.([]*okta.Role)
+	if !ok {
+		return nil, nil
+	}
+	var roleTypes = []string{}
+
+	for _, role := range roles {
+		if !slices.Contains(roleTypes, role.Type) {
+			roleTypes = append(roleTypes, role.Type)
+		}
+	}
+
+	return roleTypes, nil
 }
 
 //// other useful functions
 
+// Profile attributes that are already exposed as top level columns
+var userProfileColumnExclusions = []string{"email", "login"}
+
+// userProfileColumns returns a column for each base and custom attribute in
+// the org's default user schema, e.g. profile_employee_number for
+// employeeNumber.
+func userProfileColumns(ctx context.Context, td *plugin.TableMapData) []*plugin.Column {
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Schema/#tag/Schema/operation/getUserSchema
+	return profileSchemaColumns(ctx, td, "/api/v1/meta/schemas/user/default", true, userProfileColumnExclusions, userProfileAttribute)
+}
+
+// setUserListQuery pushes the quals and order of the query down to the
+// filter, search and sort parameters of the list users call
+func setUserListQuery(d *plugin.QueryData, input *query.Params) {
+	equalQuals := d.EqualsQuals
+	quals := d.Quals
+
+	var queryFilter, querySearch string
+	filter := buildUserQueryFilter(equalQuals)
+
+	// TODO - optimize or move it to a utility function
+	// https://developer.okta.com/docs/reference/api-overview/#operators
+	if quals["last_updated"] != nil {
+		for _, q := range quals["last_updated"].Quals {
+			timeString := q.Value.GetTimestampValue().AsTime().Format(filterTimeFormat)
+			filter = append(filter, fmt.Sprintf("%s %s \"%s\"", "lastUpdated", operatorsMap[q.Operator], timeString))
+		}
+	}
+
+	if equalQuals["filter"] != nil {
+		queryFilter = equalQuals["filter"].GetStringValue()
+	}
+
+	if equalQuals["search"] != nil {
+		querySearch = equalQuals["search"].GetStringValue()
+	}
+
+	// The filter parameter only supports lastUpdated, so the other timestamp
+	// and user type quals are translated into a search expression instead
+	// https://developer.okta.com/docs/reference/api/users/#list-users-with-search
+	search := buildUserQuerySearch(quals)
+	if equalQuals["user_type_id"] != nil {
+		search = append(search, fmt.Sprintf("type.id eq \"%s\"", equalQuals["user_type_id"].GetStringValue()))
+	}
+
+	// Expressions given in the filter or search columns are passed verbatim
+	// and take precedence over the ones built from the other quals
+	if queryFilter != "" || querySearch != "" {
+		input.Filter = queryFilter
+		input.Search = querySearch
+	} else if len(search) > 0 {
+		// search supports every expression the filter does, so move them
+		// across rather than combining both parameters
+		input.Search = strings.Join(append(filter, search...), " and ")
+	} else if len(filter) > 0 {
+		input.Filter = strings.Join(filter, " and ")
+	}
+
+	// Okta only sorts search results, so the filter expressions, which are
+	// valid search expressions too, are moved across when the order is pushed down
+	// https://developer.okta.com/docs/reference/api/users/#list-users-with-search
+	if sortBy, sortOrder := getUserSortOrder(d.QueryContext.SortOrder); sortBy != "" {
+		input.SortBy = sortBy
+		input.SortOrder = sortOrder
+		if input.Filter != "" {
+			if input.Search != "" {
+				input.Search = fmt.Sprintf("(%s) and (%s)", input.Search, input.Filter)
+			} else {
+				input.Search = input.Filter
+			}
+			input.Filter = ""
+		}
+		if input.Search == "" {
+			// Match the users returned when listing without a filter
+			input.Search = "status ne \"DEPROVISIONED\""
+		}
+	}
+}
+
 func buildUserQueryFilter(equalQuals plugin.KeyColumnEqualsQualMap) []string {
 	filters := []string{}
 
@@ -301,10 +556,83 @@ func buildUserQueryFilter(equalQuals plugin.KeyColumnEqualsQualMap) []string {
 	}
 
 	for qual, filterColumn := range filterQuals {
-		if equalQuals[qual] != nil {
-			filters = append(filters, fmt.Sprintf("%s eq \"%s\"", filterColumn, equalQuals[qual].GetStringValue()))
+		if equalQuals[qual] == nil {
+			continue
+		}
+		// Lists such as status in ('ACTIVE', 'DEPROVISIONED') are ORed together.
+		// Deprovisioned users are only returned when the status is filtered on explicitly.
+		if listValue := equalQuals[qual].GetListValue(); listValue != nil {
+			var values []string
+			for _, value := range getListValues(listValue) {
+				values = append(values, fmt.Sprintf("%s eq \"%s\"", filterColumn, *value))
+			}
+			if len(values) > 0 {
+				filters = append(filters, "("+strings.Join(values, " or ")+")")
+			}
+			continue
 		}
+		filters = append(filters, fmt.Sprintf("%s eq \"%s\"", filterColumn, equalQuals[qual].GetStringValue()))
 	}
 
 	return filters
 }
+
+// Timestamp columns that can be pushed down as search expressions, in the
+// order they are added to the expression
+var userSearchTimestampColumns = []struct {
+	column    string
+	attribute string
+}{
+	{"created", "created"},
+	{"activated", "activated"},
+	{"last_login", "lastLogin"},
+	{"password_changed", "passwordChanged"},
+	{"status_changed", "statusChanged"},
+}
+
+// Columns the Users API can sort search results on, i.e. the top-level
+// timestamp attributes of a user
+// https://developer.okta.com/docs/reference/api/users/#list-users-with-search
+var userSortColumns = map[string]string{
+	"created":          "created",
+	"activated":        "activated",
+	"last_login":       "lastLogin",
+	"last_updated":     "lastUpdated",
+	"password_changed": "passwordChanged",
+	"status_changed":   "statusChanged",
+}
+
+// getUserSortOrder returns the sortBy and sortOrder parameters for the order
+// pushed down by Steampipe. Okta sorts on a single attribute, so the order is
+// only pushed down when it is on a single column, or ties of the first column
+// would be returned in the wrong order.
+func getUserSortOrder(sortColumns []*plugin.SortColumn) (string, string) {
+	if len(sortColumns) != 1 {
+		return "", ""
+	}
+
+	sortBy, ok := userSortColumns[sortColumns[0].Column]
+	if !ok {
+		return "", ""
+	}
+	if sortColumns[0].Order == plugin.SortDesc {
+		return sortBy, "desc"
+	}
+	return sortBy, "asc"
+}
+
+func buildUserQuerySearch(quals plugin.KeyColumnQualMap) []string {
+	search := []string{}
+
+	for _, timestampColumn := range userSearchTimestampColumns {
+		if quals[timestampColumn.column] == nil {
+			continue
+		}
+		for _, q := range quals[timestampColumn.column].Quals {
+			timeString := q.Value.GetTimestampValue().AsTime().Format(filterTimeFormat)
+			search = append(search, fmt.Sprintf("%s %s \"%s\"", timestampColumn.attribute, operatorsMap[q.Operator], timeString))
+		}
+	}
+
+	return search
+}
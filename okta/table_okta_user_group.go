@@ -0,0 +1,179 @@
+package okta
+
+import (
+	"context"
+
+	"github.com/okta/okta-sdk-golang/v2/okta"
+	"github.com/okta/okta-sdk-golang/v2/okta/query"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaUserGroup() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_user_group",
+		Description: "Represents the membership of a user in a group.",
+		List: &plugin.ListConfig{
+			Hydrate: listOktaUserGroups,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "user_id", Require: plugin.Optional},
+				{Name: "group_id", Require: plugin.Optional},
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "user_id", Type: proto.ColumnType_STRING, Description: "Unique key for the user. Specify it to only list the groups of the user."},
+			{Name: "group_id", Type: proto.ColumnType_STRING, Description: "Unique key for the group. Specify it to only list the members of the group."},
+			{Name: "login", Type: proto.ColumnType_STRING, Description: "Unique identifier for the user (username)."},
+			{Name: "group_name", Type: proto.ColumnType_STRING, Description: "Name of the group."},
+
+			// Other Columns
+			{Name: "email", Type: proto.ColumnType_STRING, Description: "Primary email address of the user."},
+			{Name: "group_type", Type: proto.ColumnType_STRING, Description: "Determines how a group's profile and memberships are managed. Can be one of OKTA_GROUP, APP_GROUP or BUILT_IN."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.FromField("Login"), Description: titleDescription},
+		}),
+	}
+}
+
+type UserGroup struct {
+	UserId    string
+	GroupId   string
+	Login     string
+	Email     string
+	GroupName string
+	GroupType string
+
+	user  *okta.User
+	group *okta.Group
+}
+
+func (m UserGroup) RawObject() interface{} {
+	return map[string]interface{}{"user": m.user, "group": m.group}
+}
+
+func newUserGroup(user *okta.User, group *okta.Group) UserGroup {
+	membership := UserGroup{UserId: user.Id, GroupId: group.Id, GroupType: group.Type, user: user, group: group}
+	if user.Profile != nil {
+		membership.Login, _ = (*user.Profile)["login"].(string)
+		membership.Email, _ = (*user.Profile)["email"].(string)
+	}
+	if group.Profile != nil {
+		membership.GroupName = group.Profile.Name
+	}
+	return membership
+}
+
+//// LIST FUNCTION
+
+// listOktaUserGroups lists the memberships from whichever side is given. The
+// groups of a user are listed when user_id is given, as users belong to far
+// fewer groups than groups have members, and the members of the group when
+// only group_id is given. Otherwise the members of every group are listed.
+func listOktaUserGroups(ctx context.Context, d *plugin.QueryData, _ *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("okta_user_group.listOktaUserGroups", "connect_error", err)
+		return nil, err
+	}
+
+	userId := d.EqualsQualString("user_id")
+	groupId := d.EqualsQualString("group_id")
+
+	if userId != "" {
+		user, resp, err := client.User.GetUser(ctx, userId)
+		if err != nil {
+			if isNotFoundError(err) {
+				return nil, nil
+			}
+			logger.Error("okta_user_group.listOktaUserGroups", "get_user_error", err)
+			return nil, withStatusCode(err, resp)
+		}
+
+		groups, resp, err := client.User.ListUserGroups(ctx, userId)
+		if err != nil {
+			logger.Error("okta_user_group.listOktaUserGroups", "list_user_groups_error", err)
+			return nil, withStatusCode(err, resp)
+		}
+
+		err = streamPages(ctx, d, groups, v2Pages[*okta.Group](ctx, resp), func(group *okta.Group) interface{} {
+			if groupId != "" && group.Id != groupId {
+				return nil
+			}
+			return newUserGroup(user, group)
+		})
+		if err != nil {
+			logger.Error("okta_user_group.listOktaUserGroups", "list_user_groups_paging_error", err)
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if groupId != "" {
+		group, resp, err := client.Group.GetGroup(ctx, groupId)
+		if err != nil {
+			if isNotFoundError(err) {
+				return nil, nil
+			}
+			logger.Error("okta_user_group.listOktaUserGroups", "get_group_error", err)
+			return nil, withStatusCode(err, resp)
+		}
+		return nil, streamGroupMemberships(ctx, d, client, group)
+	}
+
+	// Default maximum limit set as per documentation
+	// https://developer.okta.com/docs/reference/api/groups/#list-groups
+	groups, resp, err := client.Group.ListGroups(ctx, &query.Params{Limit: getPageSize(d, 10000)})
+	if err != nil {
+		logger.Error("okta_user_group.listOktaUserGroups", "list_groups_error", err)
+		return nil, withStatusCode(err, resp)
+	}
+
+	var membersErr error
+	err = forEachPage(ctx, groups, v2Pages[*okta.Group](ctx, resp), func(page []*okta.Group) bool {
+		for _, group := range page {
+			if membersErr = streamGroupMemberships(ctx, d, client, group); membersErr != nil || d.RowsRemaining(ctx) == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logger.Error("okta_user_group.listOktaUserGroups", "list_groups_paging_error", err)
+		return nil, err
+	}
+	if membersErr != nil {
+		return nil, membersErr
+	}
+
+	return nil, nil
+}
+
+// streamGroupMemberships streams a row for each member of the group
+func streamGroupMemberships(ctx context.Context, d *plugin.QueryData, client *okta.Client, group *okta.Group) error {
+	logger := plugin.Logger(ctx)
+
+	// The API returns 1000 members per page by default and up to 10000
+	// https://developer.okta.com/docs/api/openapi/okta-management/management/tag/Group/#tag/Group/operation/listGroupUsers
+	users, resp, err := client.Group.ListGroupUsers(ctx, group.Id, &query.Params{Limit: getListPageSize(d, 10000)})
+	if err != nil {
+		logger.Error("okta_user_group.streamGroupMemberships", "list_group_users_error", err)
+		return withStatusCode(err, resp)
+	}
+
+	err = streamPages(ctx, d, users, v2Pages[*okta.User](ctx, resp), func(user *okta.User) interface{} {
+		return newUserGroup(user, group)
+	})
+	if err != nil {
+		logger.Error("okta_user_group.streamGroupMemberships", "list_group_users_paging_error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package okta
+
+import (
+	"context"
+	"slices"
+
+	"github.com/okta/okta-sdk-golang/v2/okta"
+	oktav4 "github.com/okta/okta-sdk-golang/v4/okta"
+	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
+)
+
+//// TABLE DEFINITION
+
+func tableOktaUserMfaSummary() *plugin.Table {
+	return &plugin.Table{
+		Name:        "okta_user_mfa_summary",
+		Description: "Summarizes the MFA factors enrolled by each Okta user.",
+		Get: &plugin.GetConfig{
+			Hydrate:      getOktaUserMfaSummaryUser,
+			KeyColumns:   plugin.SingleColumn("user_id"),
+			IgnoreConfig: &plugin.IgnoreConfig{ShouldIgnoreErrorFunc: shouldIgnoreNotFoundError()},
+		},
+		List: &plugin.ListConfig{
+			Hydrate: listOktaUsers,
+			KeyColumns: plugin.KeyColumnSlice{
+				{Name: "login", Require: plugin.Optional},
+				{Name: "email", Require: plugin.Optional},
+				{Name: "status", Require: plugin.Optional},
+			},
+		},
+		HydrateConfig: []plugin.HydrateConfig{
+			{
+				Func:           getUserMfaSummary,
+				MaxConcurrency: 10,
+			},
+		},
+		Columns: commonColumns([]*plugin.Column{
+			// Top Columns
+			{Name: "user_id", Type: proto.ColumnType_STRING, Transform: transform.FromField("Id"), Description: "Unique key for the user."},
+			{Name: "login", Type: proto.ColumnType_STRING, Transform: transform.From(userProfile), Description: "Unique identifier for the user (username)."},
+			{Name: "email", Type: proto.ColumnType_STRING, Transform: transform.From(userProfile), Description: "Primary email address of user."},
+			{Name: "status", Type: proto.ColumnType_STRING, Description: "Current status of user."},
+
+			// MFA Columns
+			{Name: "factor_count", Type: proto.ColumnType_INT, Hydrate: getUserMfaSummary, Transform: transform.FromField("FactorCount"), Description: "The number of factors enrolled by the user, regardless of status."},
+			{Name: "active_factor_count", Type: proto.ColumnType_INT, Hydrate: getUserMfaSummary, Transform: transform.FromField("ActiveFactorCount"), Description: "The number of ACTIVE factors enrolled by the user."},
+			{Name: "has_webauthn", Type: proto.ColumnType_BOOL, Hydrate: getUserMfaSummary, Transform: transform.FromField("HasWebauthn"), Description: "True if the user has an active WebAuthn or U2F factor."},
+			{Name: "has_push", Type: proto.ColumnType_BOOL, Hydrate: getUserMfaSummary, Transform: transform.FromField("HasPush"), Description: "True if the user has an active push or Okta FastPass factor."},
+			{Name: "has_otp", Type: proto.ColumnType_BOOL, Hydrate: getUserMfaSummary, Transform: transform.FromField("HasOtp"), Description: "True if the user has an active one-time passcode factor (software TOTP, HOTP or hardware token)."},
+			{Name: "has_sms_only", Type: proto.ColumnType_BOOL, Hydrate: getUserMfaSummary, Transform: transform.FromField("HasSmsOnly"), Description: "True if SMS is the only active factor type enrolled by the user."},
+			{Name: "strongest_factor", Type: proto.ColumnType_STRING, Hydrate: getUserMfaSummary, Transform: transform.FromField("StrongestFactor"), Description: "The factor type of the strongest active factor enrolled by the user."},
+
+			// JSON Columns
+			{Name: "factor_types", Type: proto.ColumnType_JSON, Hydrate: getUserMfaSummary, Transform: transform.FromField("FactorTypes"), Description: "The distinct factor types of the active factors enrolled by the user."},
+
+			// Steampipe Columns
+			{Name: "title", Type: proto.ColumnType_STRING, Transform: transform.From(userProfile), Description: titleDescription},
+		}),
+	}
+}
+
+type UserMfaSummary struct {
+	FactorCount       int
+	ActiveFactorCount int
+	HasWebauthn       bool
+	HasPush           bool
+	HasOtp            bool
+	HasSmsOnly        bool
+	StrongestFactor   *string
+	FactorTypes       []string
+}
+
+// Relative strength of each factor type, phishing resistant factors rank highest
+var factorTypeStrength = map[string]int{
+	"webauthn":            100,
+	"u2f":                 100,
+	"signed_nonce":        90,
+	"push":                80,
+	"token:hardware":      70,
+	"token:software:totp": 60,
+	"token:hotp":          60,
+	"token":               60,
+	"sms":                 30,
+	"call":                30,
+	"email":               20,
+	"question":            10,
+}
+
+//// HYDRATE FUNCTIONS
+
+func getOktaUserMfaSummaryUser(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	userId := d.EqualsQualString("user_id")
+	if userId == "" {
+		return nil, nil
+	}
+
+	client, err := Connect(ctx, d)
+	if err != nil {
+		logger.Error("okta_user_mfa_summary.getOktaUserMfaSummaryUser", "connect_error", err)
+		return nil, err
+	}
+
+	user, _, err := client.User.GetUser(ctx, userId)
+	if err != nil {
+		logger.Error("okta_user_mfa_summary.getOktaUserMfaSummaryUser", "api_error", err)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func getUserMfaSummary(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	logger := plugin.Logger(ctx)
+	userId := h.Item.(*okta.User).Id
+
+	client, err := ConnectV4(ctx, d)
+	if err != nil {
+		logger.Error("okta_user_mfa_summary.getUserMfaSummary", "connect_error", err)
+		return nil, err
+	}
+
+	factors, resp, err := client.UserFactorAPI.ListFactors(ctx, userId).Execute()
+	if err != nil {
+		logger.Error("okta_user_mfa_summary.getUserMfaSummary", "api_error", err)
+		if isNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Each page is reduced to the factor details the summary is built from
+	userFactors := make([]OktaFactor, 0, len(factors))
+	err = forEachPage(ctx, factors, v4Pages[oktav4.ListFactors200ResponseInner](resp), func(page []oktav4.ListFactors200ResponseInner) bool {
+		userFactors = appendFactorDetails(userFactors, page)
+		return true
+	})
+	if err != nil {
+		logger.Error("okta_user_mfa_summary.getUserMfaSummary", "api_paging_error", err)
+		return nil, err
+	}
+
+	return summarizeUserFactors(userFactors), nil
+}
+
+//// UTILITY FUNCTION
+
+func appendFactorDetails(userFactors []OktaFactor, factors []oktav4.ListFactors200ResponseInner) []OktaFactor {
+	for _, factor := range factors {
+		if factor.GetActualInstance() != nil {
+			userFactors = append(userFactors, getFactorDetails(factor.GetActualInstance()))
+		}
+	}
+
+	return userFactors
+}
+
+func summarizeUserFactors(factors []OktaFactor) UserMfaSummary {
+	summary := UserMfaSummary{
+		FactorCount: len(factors),
+		FactorTypes: []string{},
+	}
+
+	strongest := 0
+	onlySms := true
+	for _, factor := range factors {
+		if factor.GetStatus() != "ACTIVE" {
+			continue
+		}
+		factorType := factor.GetFactorType()
+		summary.ActiveFactorCount++
+
+		switch factorType {
+		case "webauthn", "u2f":
+			summary.HasWebauthn = true
+		case "push", "signed_nonce":
+			summary.HasPush = true
+		case "token:software:totp", "token:hotp", "token:hardware", "token":
+			summary.HasOtp = true
+		}
+		if factorType != "sms" {
+			onlySms = false
+		}
+
+		if !slices.Contains(summary.FactorTypes, factorType) {
+			summary.FactorTypes = append(summary.FactorTypes, factorType)
+		}
+		if factorTypeStrength[factorType] > strongest {
+			strongest = factorTypeStrength[factorType]
+			t := factorType
+			summary.StrongestFactor = &t
+		}
+	}
+	summary.HasSmsOnly = summary.ActiveFactorCount > 0 && onlySms
+
+	return summary
+}
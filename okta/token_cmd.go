@@ -0,0 +1,117 @@
+package okta
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum time the token_cmd command may run for
+const tokenCommandTimeout = time.Minute
+
+// API tokens read from the output of token_cmd commands, keyed by command
+var commandTokens sync.Map
+
+type commandToken struct {
+	mu    sync.Mutex
+	token string
+}
+
+// getCommandToken returns the API token printed by the command, running it
+// the first time the token is needed
+func getCommandToken(command string) (string, error) {
+	return refreshCommandToken(command, "")
+}
+
+// refreshCommandToken runs the command again to get a new API token once the
+// stale token is rejected. Concurrent requests rejected with the same token
+// only run the command once.
+func refreshCommandToken(command, stale string) (string, error) {
+	value, _ := commandTokens.LoadOrStore(command, &commandToken{})
+	cached := value.(*commandToken)
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+
+	if cached.token != "" && cached.token != stale {
+		return cached.token, nil
+	}
+	token, err := runTokenCommand(command)
+	if err != nil {
+		return "", err
+	}
+	cached.token = token
+	return token, nil
+}
+
+func runTokenCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("token_cmd failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", errors.New("token_cmd printed no API token")
+	}
+	return token, nil
+}
+
+// commandTokenTransport sends requests with the API token printed by the
+// token_cmd command, running the command again and retrying once when the
+// token is rejected, e.g. after it was rotated in the secret manager
+type commandTokenTransport struct {
+	base    http.RoundTripper
+	command string
+}
+
+func (t *commandTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := getCommandToken(t.command)
+	if err != nil {
+		return nil, err
+	}
+	tokenReq := req.Clone(req.Context())
+	tokenReq.Header.Set("Authorization", "SSWS "+token)
+	resp, err := t.base.RoundTrip(tokenReq)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// Requests with a body can only be sent again if the body can be read again
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	newToken, err := refreshCommandToken(t.command, token)
+	if err != nil || newToken == token {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	retry.Header.Set("Authorization", "SSWS "+newToken)
+	return t.base.RoundTrip(retry)
+}
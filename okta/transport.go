@@ -0,0 +1,647 @@
+package okta
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+)
+
+// Default percentage of an endpoint's rate limit left below which requests
+// to the endpoint are slowed down
+const defaultRateLimitThreshold = 20
+
+// Default maximum total time to wait for rate limits to reset when retrying
+// a request
+const defaultMaxRateLimitWait = 300 * time.Second
+
+// Default number of consecutive failed requests to an endpoint after which
+// further requests to it fail fast
+const defaultCircuitBreakerThreshold = 5
+
+// Default time requests to an endpoint fail fast for once its circuit opens
+const defaultCircuitBreakerCooldown = 60 * time.Second
+
+// Default timeouts for opening connections to Okta
+const (
+	defaultDialTimeout         = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// Number of idle connections kept open to Okta. All requests of a connection
+// go to the same host, so as many as the requests the plugin runs concurrently
+// are kept rather than the two per host kept by default.
+const maxIdleConns = 100
+
+// HTTP clients shared by the v2, v4 and v5 SDK clients of each connection, so
+// they all see the rate limits consumed by each other
+var httpClients sync.Map
+
+// getHTTPClient returns the HTTP client used by the SDK clients of the
+// connection. The client is rebuilt when the transport settings change.
+func getHTTPClient(connection *plugin.Connection, clientConfig oktaClientConfig) (*http.Client, error) {
+	config := GetConfig(connection)
+	maxRetries, maxBackoff := clientConfig.maxRetries, clientConfig.maxBackoff
+
+	threshold := defaultRateLimitThreshold
+	if config.RateLimitThreshold != nil && *config.RateLimitThreshold >= 0 {
+		threshold = *config.RateLimitThreshold
+	}
+	maxWait := getMaxRateLimitWait(config)
+	breakerThreshold := defaultCircuitBreakerThreshold
+	if config.CircuitBreakerThreshold != nil && *config.CircuitBreakerThreshold >= 0 {
+		breakerThreshold = *config.CircuitBreakerThreshold
+	}
+	breakerCooldown := defaultCircuitBreakerCooldown
+	if config.CircuitBreakerCooldown != nil && *config.CircuitBreakerCooldown >= 0 {
+		breakerCooldown = time.Duration(*config.CircuitBreakerCooldown) * time.Second
+	}
+	dialTimeout := defaultDialTimeout
+	if config.DialTimeout != nil && *config.DialTimeout > 0 {
+		dialTimeout = time.Duration(*config.DialTimeout) * time.Second
+	}
+	tlsHandshakeTimeout := defaultTLSHandshakeTimeout
+	if config.TLSHandshakeTimeout != nil && *config.TLSHandshakeTimeout > 0 {
+		tlsHandshakeTimeout = time.Duration(*config.TLSHandshakeTimeout) * time.Second
+	}
+
+	for _, class := range config.RetryOn {
+		if !slices.Contains(retryClasses, class) {
+			return nil, fmt.Errorf("invalid retry_on value %q: must be one of %s", class, strings.Join(retryClasses, ", "))
+		}
+	}
+
+	httpLogLevel := httpLogOff
+	if config.HTTPLogLevel != nil && *config.HTTPLogLevel != "" {
+		httpLogLevel = *config.HTTPLogLevel
+		if !slices.Contains(httpLogLevels, httpLogLevel) {
+			return nil, fmt.Errorf("invalid http_log_level %q: must be one of %s", httpLogLevel, strings.Join(httpLogLevels, ", "))
+		}
+	}
+
+	var proxyURL *url.URL
+	if config.ProxyURL != nil && *config.ProxyURL != "" {
+		var err error
+		if proxyURL, err = url.Parse(*config.ProxyURL); err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy_url %q: a URL like http://proxy.example.com:3128 is expected", *config.ProxyURL)
+		}
+	}
+
+	// Tokens of token_cmd and access tokens are sent by the transport, and
+	// DPoP only applies to service apps, whose key also signs the client
+	// assertions the DPoP transport sends
+	var authKey string
+	var tokenCmd, accessToken, dpop bool
+	switch {
+	case clientConfig.token != "":
+	case clientConfig.tokenCmd != "":
+		tokenCmd = true
+		authKey = fmt.Sprintf("%x", sha256.Sum256([]byte(clientConfig.tokenCmd)))
+	case clientConfig.accessToken != "":
+		accessToken = true
+		authKey = fmt.Sprintf("%x", sha256.Sum256([]byte(clientConfig.accessToken)))
+	case clientConfig.dpop && clientConfig.clientID != "" && clientConfig.privateKey != "":
+		dpop = true
+		authKey = fmt.Sprintf("%x", sha256.Sum256([]byte(clientConfig.clientID+clientConfig.privateKeyID+clientConfig.privateKey)))
+	}
+
+	var name string
+	if connection != nil {
+		name = connection.Name
+	}
+	key := fmt.Sprintf("%s-%d-%d-%d-%d-%s-%v-%d-%s-%s-%s-%s-%s-%s", name, threshold, maxRetries, maxBackoff, clientConfig.requestTimeout, maxWait, config.RetryOn, breakerThreshold, breakerCooldown, dialTimeout, tlsHandshakeTimeout, proxyURL, httpLogLevel, authKey)
+	if client, ok := httpClients.Load(key); ok {
+		return client.(*http.Client), nil
+	}
+
+	var transport http.RoundTripper = newBaseTransport(dialTimeout, tlsHandshakeTimeout, proxyURL)
+	// Each attempt of a request is logged
+	if httpLogLevel != httpLogOff {
+		transport = &loggingTransport{base: transport, level: httpLogLevel}
+	}
+	// The rate limits are recorded for okta_connection_info even when
+	// requests are never delayed, i.e. with a threshold of 0
+	transport = &rateLimitTransport{
+		base:       transport,
+		threshold:  threshold,
+		connection: name,
+		limits:     map[string]*rateLimitState{},
+	}
+	if tokenCmd {
+		transport = &commandTokenTransport{base: transport, command: clientConfig.tokenCmd}
+	}
+	if accessToken {
+		transport = &bearerTokenTransport{base: transport, token: clientConfig.accessToken}
+	}
+	// Each attempt of a request gets a new DPoP proof
+	if dpop {
+		var err error
+		transport, err = newDPoPTransport(transport, clientConfig.clientID, clientConfig.privateKey, clientConfig.privateKeyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	transport = &retryTransport{
+		base:             transport,
+		maxAttempts:      int(maxRetries) + 1,
+		maxBackoff:       time.Duration(maxBackoff) * time.Second,
+		maxRateLimitWait: maxWait,
+		attemptTimeout:   time.Duration(clientConfig.requestTimeout) * time.Second,
+		retryOn:          config.RetryOn,
+	}
+	if breakerThreshold > 0 {
+		transport = &circuitBreakerTransport{
+			base:      transport,
+			threshold: breakerThreshold,
+			cooldown:  breakerCooldown,
+			circuits:  map[string]*circuitState{},
+		}
+	}
+	transport = &metricsTransport{base: transport, connection: name}
+
+	client, _ := httpClients.LoadOrStore(key, &http.Client{Transport: transport})
+	return client.(*http.Client), nil
+}
+
+// getMaxRateLimitWait returns the maximum total time to wait for rate limits
+// to reset when retrying a request
+func getMaxRateLimitWait(config oktaConfig) time.Duration {
+	if config.MaxRateLimitWait != nil && *config.MaxRateLimitWait >= 0 {
+		return time.Duration(*config.MaxRateLimitWait) * time.Second
+	}
+	return defaultMaxRateLimitWait
+}
+
+// newBaseTransport returns the transport sending the requests to Okta. Like
+// the default transport it negotiates HTTP/2, keeps connections alive and
+// requests gzip compressed responses, which it decompresses transparently as
+// the SDKs don't set Accept-Encoding themselves. Requests go through the proxy
+// if set, or else through the proxy of the HTTPS_PROXY and NO_PROXY
+// environment variables.
+func newBaseTransport(dialTimeout, tlsHandshakeTimeout time.Duration, proxyURL *url.URL) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != nil {
+		// Credentials in the URL are sent with basic auth
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	// HTTP/2 is only attempted with a custom dialer when forced
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConns
+	return transport
+}
+
+// retryTransport retries rate limited (429) requests once the rate limit of
+// the endpoint resets, waiting up to maxRateLimitWait in total, and retries GET
+// requests that failed with a transient server or connection error after an
+// exponential backoff. Up to maxAttempts attempts are made, after which the
+// last response or error is returned. Attempts taking longer than
+// attemptTimeout fail, so a hung request is retried rather than stalling the
+// query until the SDK call times out.
+type retryTransport struct {
+	base             http.RoundTripper
+	maxAttempts      int
+	maxBackoff       time.Duration
+	maxRateLimitWait time.Duration
+	attemptTimeout   time.Duration
+	// Classes of failures retried, all of them if empty
+	retryOn []string
+}
+
+// Classes of failures the retry_on setting can list
+const (
+	retryOnRateLimit       = "rate_limit"
+	retryOnServerError     = "server_error"
+	retryOnConnectionError = "connection_error"
+	retryOnTimeout         = "timeout"
+)
+
+var retryClasses = []string{retryOnRateLimit, retryOnServerError, retryOnConnectionError, retryOnTimeout}
+
+// errAttemptTimeout is returned for attempts of a request that timed out
+var errAttemptTimeout = errors.New("request timed out")
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var rateLimitWaited time.Duration
+	for attempt := 1; ; attempt++ {
+		resp, err := t.roundTripAttempt(req)
+		if attempt >= t.maxAttempts {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if !isIdempotent(req) || !isTransientError(err) || !t.retries(retryClassOf(err)) {
+				return nil, err
+			}
+			wait = t.backoff(attempt)
+		case resp.StatusCode == http.StatusTooManyRequests && t.retries(retryOnRateLimit):
+			// Retrying before the rate limit resets would only be rate limited again
+			wait = rateLimitResetWait(resp.Header, time.Now())
+			if rateLimitWaited+wait > t.maxRateLimitWait {
+				return resp, nil
+			}
+			rateLimitWaited += wait
+			getQueryMetrics(req.Context()).addRateLimitWait(wait)
+		case isIdempotent(req) && isTransientStatus(resp.StatusCode) && t.retries(retryOnServerError):
+			wait = t.backoff(attempt)
+		default:
+			return resp, nil
+		}
+
+		// Requests with a body can only be retried if the body can be read again
+		retry := req.Clone(req.Context())
+		if req.Body != nil && req.Body != http.NoBody {
+			var bodyErr error
+			if req.GetBody == nil {
+				return resp, err
+			}
+			if retry.Body, bodyErr = req.GetBody(); bodyErr != nil {
+				return resp, err
+			}
+		}
+		retry.Header.Set("X-Okta-Retry-Count", strconv.Itoa(attempt))
+		getQueryMetrics(req.Context()).addRetry()
+		if resp != nil {
+			retry.Header.Set("X-Okta-Retry-For", resp.Header.Get("X-Okta-Request-Id"))
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		req = retry
+	}
+}
+
+func (t *retryTransport) retries(class string) bool {
+	return len(t.retryOn) == 0 || slices.Contains(t.retryOn, class)
+}
+
+// retryClassOf returns the class of a transient request error
+func retryClassOf(err error) string {
+	if errors.Is(err, errAttemptTimeout) {
+		return retryOnTimeout
+	}
+	return retryOnConnectionError
+}
+
+// roundTripAttempt sends a single attempt of the request, which fails once
+// sending it and reading its response takes longer than attemptTimeout
+func (t *retryTransport) roundTripAttempt(req *http.Request) (*http.Response, error) {
+	if t.attemptTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, fmt.Errorf("%w after %s: %s %s", errAttemptTimeout, t.attemptTimeout, req.Method, req.URL.Path)
+		}
+		return nil, err
+	}
+	// The deadline also applies to reading the body
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnCloseBody releases the context of an attempt once its response body
+// is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// backoff returns the wait before the given retry, doubling from one second up
+// to maxBackoff, with a random jitter so concurrent requests don't retry together
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	wait := t.maxBackoff
+	if attempt < 16 && time.Second<<(attempt-1) < wait {
+		wait = time.Second << (attempt - 1)
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func isIdempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// Gateway errors returned while Okta or a proxy in front of it is unavailable
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusBadGateway || statusCode == http.StatusServiceUnavailable || statusCode == http.StatusGatewayTimeout
+}
+
+// Connections closed or reset by Okta or a proxy in front of it, and attempts
+// that timed out
+func isTransientError(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, errAttemptTimeout)
+}
+
+// bearerTokenTransport sends requests with a pre-acquired OAuth access token.
+// The v2 SDK client is built with the access token as an API token, so the
+// SSWS scheme it sends the token with is replaced.
+type bearerTokenTransport struct {
+	base  http.RoundTripper
+	token string
+}
+
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// circuitBreakerTransport fails requests to an endpoint fast once threshold
+// requests in a row to it failed, after their retries, with a connection error
+// or a server error, rather than letting a large scan wait through the
+// timeouts of every remaining request. After cooldown a single request is let
+// through to probe the endpoint, which closes the circuit again if it succeeds.
+type circuitBreakerTransport struct {
+	base      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuitState
+}
+
+type circuitState struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+	lastError string
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := rateLimitEndpoint(req.URL.Path)
+
+	if err := t.allow(endpoint, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		// Cancelled queries, e.g. once a limit is reached, aren't failures of the endpoint
+		if req.Context().Err() == nil {
+			t.record(endpoint, err.Error(), time.Now())
+		} else {
+			t.release(endpoint)
+		}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.record(endpoint, resp.Status, time.Now())
+	default:
+		t.reset(endpoint)
+	}
+
+	return resp, err
+}
+
+// allow returns an error if the circuit of the endpoint is open
+func (t *circuitBreakerTransport) allow(endpoint string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.circuits[endpoint]
+	if !ok || state.failures < t.threshold {
+		return nil
+	}
+	if now.Before(state.openUntil) {
+		return fmt.Errorf("okta: skipping request to %s after %d consecutive failed requests, retrying in %s, last error: %s", endpoint, state.failures, state.openUntil.Sub(now).Round(time.Second), state.lastError)
+	}
+	if state.probing {
+		return fmt.Errorf("okta: skipping request to %s after %d consecutive failed requests while checking whether it recovered, last error: %s", endpoint, state.failures, state.lastError)
+	}
+	state.probing = true
+	return nil
+}
+
+func (t *circuitBreakerTransport) record(endpoint string, lastError string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.circuits[endpoint]
+	if !ok {
+		state = &circuitState{}
+		t.circuits[endpoint] = state
+	}
+	state.failures++
+	state.lastError = lastError
+	state.probing = false
+	if state.failures >= t.threshold {
+		state.openUntil = now.Add(t.cooldown)
+	}
+}
+
+func (t *circuitBreakerTransport) reset(endpoint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.circuits, endpoint)
+}
+
+// release lets another request probe the endpoint when the probing request
+// was cancelled
+func (t *circuitBreakerTransport) release(endpoint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, ok := t.circuits[endpoint]; ok {
+		state.probing = false
+	}
+}
+
+// rateLimitResetWait returns the time left until the rate limit given in the
+// response headers resets. The reset time is compared with the Date header
+// to be independent of the local clock.
+func rateLimitResetWait(header http.Header, now time.Time) time.Duration {
+	reset, err := strconv.ParseInt(header.Get("X-Rate-Limit-Reset"), 10, 64)
+	if err != nil {
+		return time.Second
+	}
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		now = date
+	}
+
+	// Wait an extra second as the reset time is rounded down to the second
+	wait := time.Unix(reset, 0).Sub(now) + time.Second
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait
+}
+
+// rateLimitTransport spreads the requests to an endpoint over the time left
+// until its rate limit resets, once less than threshold percent of the limit
+// is left. Okta returns the limit of the endpoint's bucket with every response.
+// https://developer.okta.com/docs/reference/rl-best-practices/
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	threshold  int
+	connection string
+
+	mu     sync.Mutex
+	limits map[string]*rateLimitState
+}
+
+type rateLimitState struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := rateLimitEndpoint(req.URL.Path)
+
+	if delay := t.delay(endpoint, time.Now()); delay > 0 {
+		getQueryMetrics(req.Context()).addRateLimitWait(delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.update(endpoint, resp.Header)
+
+	return resp, nil
+}
+
+// delay returns how long to wait before sending a request to the endpoint.
+// The request is counted against the remaining limit straight away, so
+// concurrent requests are spread out too.
+func (t *rateLimitTransport) delay(endpoint string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.limits[endpoint]
+	if t.threshold <= 0 || !ok || state.limit == 0 || !state.reset.After(now) {
+		return 0
+	}
+
+	remaining := state.remaining
+	if state.remaining > 0 {
+		state.remaining--
+	}
+	if remaining*100 >= state.limit*t.threshold {
+		return 0
+	}
+	if remaining <= 0 {
+		return state.reset.Sub(now)
+	}
+	return state.reset.Sub(now) / time.Duration(remaining+1)
+}
+
+func (t *rateLimitTransport) update(endpoint string, header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-Rate-Limit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-Rate-Limit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	state := rateLimitState{
+		limit:     limit,
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}
+	observedRateLimits.Store(t.connection, observedRateLimit{endpoint: endpoint, rateLimitState: state})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[endpoint] = &state
+}
+
+// observedRateLimit is the rate limit of the endpoint of the most recent
+// response of a connection
+type observedRateLimit struct {
+	endpoint string
+	rateLimitState
+}
+
+// Rate limits of the most recent response of each connection, whatever the
+// table that sent the request
+var observedRateLimits sync.Map
+
+// getObservedRateLimit returns the rate limit of the most recent response of
+// the connection, if it made any API call yet
+func getObservedRateLimit(connection string) (observedRateLimit, bool) {
+	value, ok := observedRateLimits.Load(connection)
+	if !ok {
+		return observedRateLimit{}, false
+	}
+	return value.(observedRateLimit), true
+}
+
+// rateLimitEndpoint groups request paths the way Okta groups endpoints into
+// rate limit buckets, e.g. /api/v1/users/{id}/factors for the factors of any
+// user, by replacing the IDs in the path.
+func rateLimitEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if isOktaId(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Okta IDs are 20 alphanumeric characters, e.g. 00u1ab2cd3EF4gh5i6j7
+func isOktaId(segment string) bool {
+	if len(segment) != 20 {
+		return false
+	}
+	hasDigit := false
+	for _, c := range segment {
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
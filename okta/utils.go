@@ -1,14 +1,20 @@
 package okta
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"slices"
+	"sync"
+	"time"
 
 	"github.com/ettle/strcase"
+	"github.com/okta/okta-sdk-golang/v2/okta"
 	"github.com/turbot/go-kit/types"
 	"github.com/turbot/steampipe-plugin-sdk/v5/grpc/proto"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/plugin/transform"
 )
 
 const (
@@ -38,11 +44,46 @@ func getListValues(listValue *proto.QualValueList) []*string {
 
 //// other useful functions
 
+// unionType is implemented by the oneOf wrapper types of the Okta SDKs, e.g.
+// ListFactors200ResponseInner or ListPolicies200ResponseInner
+type unionType interface {
+	GetActualInstance() interface{}
+}
+
+// decodeUnionInstance decodes the actual instance of a union type into each of
+// out via its JSON representation. Unlike a type switch over the known
+// subtypes, it handles every subtype the SDK can decode, including those added
+// in later SDK versions. A nil instance decodes to nothing.
+func decodeUnionInstance(item interface{}, out ...interface{}) error {
+	if union, ok := item.(unionType); ok {
+		item = union.GetActualInstance()
+	}
+	if item == nil {
+		return nil
+	}
+	if value := reflect.ValueOf(item); value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	for _, o := range out {
+		if err := json.Unmarshal(data, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func buildQueryFilter(equalQuals plugin.KeyColumnEqualsQualMap, filterKeys []string) []string {
 	filters := []string{}
 
 	for k, v := range equalQuals {
-		if v != nil && slices.Contains(filterKeys, k) {
+		// Lists such as status in ('ACTIVE', 'INACTIVE') are left to be
+		// filtered after the API call
+		if v != nil && v.GetListValue() == nil && slices.Contains(filterKeys, k) {
 			filters = append(filters, fmt.Sprintf("%s eq \"%s\"", strcase.ToCamel(k), v.GetStringValue()))
 		}
 	}
@@ -87,3 +128,332 @@ func structToMap(input interface{}) (map[string]interface{}, error) {
 
 	return result, nil
 }
+
+// linkHref extracts the href of the HAL link named by the transform param,
+// e.g. self or accessPolicy, from the _links of an item. Links given as a list,
+// such as the logo of an app, resolve to their first href. The links may be a
+// map, as decoded by the v2 SDK client, or one of the links types of the v4
+// and v5 clients.
+func linkHref(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	if d.Value == nil || reflect.ValueOf(d.Value).Kind() == reflect.Ptr && reflect.ValueOf(d.Value).IsNil() {
+		return nil, nil
+	}
+	links, ok := d.Value.(map[string]interface{})
+	if !ok {
+		data, err := json.Marshal(d.Value)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &links); err != nil {
+			return nil, err
+		}
+	}
+
+	link := links[d.Param.(string)]
+	if list, ok := link.([]interface{}); ok {
+		if len(list) == 0 {
+			return nil, nil
+		}
+		link = list[0]
+	}
+	if object, ok := link.(map[string]interface{}); ok {
+		if href, ok := object["href"].(string); ok && href != "" {
+			return href, nil
+		}
+	}
+	return nil, nil
+}
+
+// timestampUTC converts the value of a timestamp column to UTC, whether the
+// SDK clients decoded it to a time or left it as a string, e.g. in the
+// objects of raw requests. Zero times, as set by some v4 and v5 models for
+// missing timestamps, and empty strings are null.
+func timestampUTC(_ context.Context, d *transform.TransformData) (interface{}, error) {
+	var value time.Time
+	switch v := d.Value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		value = v
+	case *time.Time:
+		if v == nil {
+			return nil, nil
+		}
+		value = *v
+	case string, *string:
+		s := types.SafeString(v)
+		if s == "" {
+			return nil, nil
+		}
+		parsed, err := parseTimestamp(s)
+		if err != nil {
+			return nil, err
+		}
+		value = parsed
+	default:
+		return d.Value, nil
+	}
+
+	if value.IsZero() {
+		return nil, nil
+	}
+	return value.UTC(), nil
+}
+
+// Layouts of the timestamps returned as strings, with or without a time zone
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parseTimestamp parses a timestamp string, taking timestamps without a time
+// zone to be in UTC
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// profileSchemaColumns returns a typed column for each attribute of the Okta
+// profile schema at schemaPath, named after the attribute with a profile_
+// prefix. Base attributes are only included if includeBase is set. If the
+// schema can't be read, e.g. because no credentials are configured yet, no
+// columns are returned and the attributes remain available in the profile
+// column.
+func profileSchemaColumns(ctx context.Context, td *plugin.TableMapData, schemaPath string, includeBase bool, exclusions []string, attributeTransform transform.TransformFunc) []*plugin.Column {
+	logger := plugin.Logger(ctx)
+	client, err := getSchemaClient(ctx, td.Connection)
+	if err != nil {
+		logger.Warn("profileSchemaColumns", "connect_error", err)
+		return nil
+	}
+
+	req, err := client.GetRequestExecutor().WithAccept("application/json").WithContentType("application/json").NewRequest("GET", schemaPath, nil)
+	if err != nil {
+		logger.Warn("profileSchemaColumns", "request_error", err)
+		return nil
+	}
+
+	var schema struct {
+		Definitions struct {
+			Base struct {
+				Properties map[string]*okta.UserSchemaAttribute `json:"properties"`
+			} `json:"base"`
+			Custom struct {
+				Properties map[string]*okta.UserSchemaAttribute `json:"properties"`
+			} `json:"custom"`
+		} `json:"definitions"`
+	}
+	_, err = client.GetRequestExecutor().Do(ctx, req, &schema)
+	if err != nil {
+		logger.Warn("profileSchemaColumns", "schema", schemaPath, "api_error", err)
+		return nil
+	}
+
+	attributes := map[string]*okta.UserSchemaAttribute{}
+	if includeBase {
+		for name, attribute := range schema.Definitions.Base.Properties {
+			attributes[name] = attribute
+		}
+	}
+	for name, attribute := range schema.Definitions.Custom.Properties {
+		attributes[name] = attribute
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		if !slices.Contains(exclusions, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	columns := []*plugin.Column{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		attribute := attributes[name]
+		columnName := "profile_" + strcase.ToSnake(name)
+		// Attributes that only differ in case would map to the same column
+		if seen[columnName] {
+			logger.Warn("profileSchemaColumns", "duplicate_column", columnName, "attribute", name)
+			continue
+		}
+		seen[columnName] = true
+
+		description := fmt.Sprintf("The %s profile attribute.", name)
+		if attribute != nil {
+			if attribute.Description != "" {
+				description = attribute.Description
+			} else if attribute.Title != "" {
+				description = fmt.Sprintf("%s (%s profile attribute).", attribute.Title, name)
+			}
+		}
+
+		columns = append(columns, &plugin.Column{
+			Name:        columnName,
+			Type:        userSchemaAttributeColumnType(attribute),
+			Transform:   transform.FromP(attributeTransform, name),
+			Description: description,
+		})
+	}
+
+	return columns
+}
+
+// Map a JSON schema type of a profile attribute to a column type
+func userSchemaAttributeColumnType(attribute *okta.UserSchemaAttribute) proto.ColumnType {
+	if attribute == nil {
+		return proto.ColumnType_STRING
+	}
+	switch attribute.Type {
+	case "boolean":
+		return proto.ColumnType_BOOL
+	case "integer":
+		return proto.ColumnType_INT
+	case "number":
+		return proto.ColumnType_DOUBLE
+	case "array", "object":
+		return proto.ColumnType_JSON
+	default:
+		return proto.ColumnType_STRING
+	}
+}
+
+// getPageSize returns the page size to request from a list API, i.e. the
+// largest size the API supports unless max_page_size is lower.
+func getPageSize(d *plugin.QueryData, apiMaxPageSize int64) int64 {
+	config := GetConfig(d.Connection)
+	if config.MaxPageSize != nil && *config.MaxPageSize > 0 && *config.MaxPageSize < apiMaxPageSize {
+		return *config.MaxPageSize
+	}
+	return apiMaxPageSize
+}
+
+// getListPageSize returns the page size of a list call, which is no larger
+// than the limit of the query, if any, so short queries fetch a single page
+func getListPageSize(d *plugin.QueryData, apiMaxPageSize int64) int64 {
+	pageSize := getPageSize(d, apiMaxPageSize)
+	if limit := d.QueryContext.Limit; limit != nil && *limit < pageSize {
+		return *limit
+	}
+	return pageSize
+}
+
+// Maximum number of concurrent get calls made for the values of an id in (...)
+// qual, which Steampipe runs all at once
+const getConcurrency = 10
+
+// Semaphores bounding the concurrent API calls of each connection, keyed by
+// connection, purpose and concurrency so a config change takes effect
+var connectionSemaphores sync.Map
+
+// acquireConnectionSemaphore waits until fewer than concurrency calls of the
+// given purpose are running for the connection. The returned func must be
+// called once the call is done.
+func acquireConnectionSemaphore(ctx context.Context, d *plugin.QueryData, purpose string, concurrency int) (func(), error) {
+	key := fmt.Sprintf("%s-%s-%d", d.Connection.Name, purpose, concurrency)
+	value, _ := connectionSemaphores.LoadOrStore(key, make(chan struct{}, concurrency))
+	semaphore := value.(chan struct{})
+
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Pages of the user, group and application lists are cached for a short time,
+// keyed by their URL, so the scans of a query listing the same resources with
+// the same parameters share the API calls, e.g. okta_user joined with
+// okta_factor, which lists the users as its parent hydrate. Pages are reused
+// for at most the TTL of the query cache, and not at all when it is disabled.
+const listPageCacheTtl = time.Minute
+
+type listPage struct {
+	items interface{}
+	resp  *okta.Response
+}
+
+// getListPage returns the page at the URL from the memoized list page
+// function, or from the uncached one when the query cache is disabled
+func getListPage(ctx context.Context, d *plugin.QueryData, url string, memoized, uncached plugin.HydrateFunc) (*listPage, error) {
+	fetch := memoized
+	if !d.QueryContext.CacheEnabled {
+		fetch = uncached
+	}
+	page, err := fetch(ctx, d, &plugin.HydrateData{Item: url})
+	if err != nil {
+		return nil, err
+	}
+	return page.(*listPage), nil
+}
+
+// Build a cache key for the calls to the memoized list page functions. The
+// URL includes the path, so it is unique across resources. The memoize TTL is
+// fixed, so the key also includes the current window of the query cache TTL,
+// when shorter, for pages to be fetched again once the window is over.
+func getListPageCacheKey(ctx context.Context, d *plugin.QueryData, h *plugin.HydrateData) (interface{}, error) {
+	ttl := listPageCacheTtl
+	if cacheTtl := time.Duration(d.QueryContext.CacheTTL) * time.Second; cacheTtl > 0 && cacheTtl < ttl {
+		ttl = cacheTtl
+	}
+	key := fmt.Sprintf("listOktaPage-%d-%s", time.Now().UnixNano()/int64(ttl), h.Item.(string))
+	return key, nil
+}
+
+// listOktaPage fetches a page of a list call into v. The URL is the path and
+// query of the first page, or the next page link of the previous response.
+func listOktaPage(ctx context.Context, client *okta.Client, url string, v interface{}) (*okta.Response, error) {
+	requestExecutor := client.GetRequestExecutor()
+	req, err := requestExecutor.WithAccept("application/json").WithContentType("application/json").NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := requestExecutor.Do(ctx, req, v)
+	return resp, withStatusCode(err, resp)
+}
+
+// Number of times a page of a list that failed after the transport retries,
+// e.g. because the request timed out, is requested again with the same cursor
+const pageRetries = 2
+
+// retryListPage calls fetch until it succeeds or the page retries run out.
+// Errors returned by the API are not retried, as the transport already
+// retried the transient ones.
+func retryListPage(ctx context.Context, fetch func() error) error {
+	err := fetch()
+	for attempt := 1; attempt <= pageRetries && err != nil; attempt++ {
+		if len(errorCodes(err)) > 0 || ctx.Err() != nil {
+			return err
+		}
+		plugin.Logger(ctx).Warn("retryListPage", "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(time.Duration(attempt) * time.Second)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		err = fetch()
+	}
+	return err
+}
+
+// pagingError returns the error of a page that failed mid-way through a list.
+// When the connection allows partial results, the error is logged instead, so
+// the rows already streamed are returned.
+func pagingError(ctx context.Context, d *plugin.QueryData, err error) error {
+	if config := GetConfig(d.Connection); config.AllowPartialResults != nil && *config.AllowPartialResults {
+		plugin.Logger(ctx).Warn("pagingError", "table", d.Table.Name, "returning_partial_results", err)
+		return nil
+	}
+	return err
+}